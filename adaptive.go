@@ -0,0 +1,140 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Bounds of request interval per host, while the host rate limits checks
+const (
+	minThrottleDelay = 250 * time.Millisecond
+	maxThrottleDelay = 30 * time.Second
+)
+
+// Concurrency and request interval of a single host. They're adjusted by AIMD: halved (doubled)
+// on 429/503 responses (once per burst) and ramped back up additively while responses succeed.
+// Fixed host limits (--host-limits) bound them: concurrency never exceeds max, interval never drops below floor
+type hostThrottle struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	limit   float64 // concurrent requests
+	max     float64
+	active  int
+	delay   time.Duration // interval between requests
+	floor   time.Duration // minimal interval of host's rate limit
+	next    time.Time     // earliest time of the next request
+	reduced time.Time     // last decrease, responses to earlier requests don't decrease limits again
+}
+
+// Per-host adaptive limiter of link checks
+type adaptiveLimiter struct {
+	mu     sync.Mutex
+	max    int
+	limits map[string]hostLimit
+	hosts  map[string]*hostThrottle
+}
+
+// Returns limiter, which allows up to max concurrent requests per host. Limits override max of their hosts
+func newAdaptiveLimiter(max int, limits map[string]hostLimit) *adaptiveLimiter {
+	if max < 1 {
+		max = 1
+	}
+	return &adaptiveLimiter{max: max, limits: limits, hosts: map[string]*hostThrottle{}}
+}
+
+func (a *adaptiveLimiter) host(name string) *hostThrottle {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	h, ok := a.hosts[name]
+	if !ok {
+		h = &hostThrottle{limit: float64(a.max), max: float64(a.max)}
+		if l, found := matchHostLimit(a.limits, (&url.URL{Host: name}).Hostname()); found {
+			h.limit, h.max = float64(l.concurrency), float64(l.concurrency)
+			h.floor, h.delay = l.interval(), l.interval()
+		}
+		h.cond = sync.NewCond(&h.mu)
+		a.hosts[name] = h
+	}
+	return h
+}
+
+// Waits for a request slot of the link's host. Returned function must be called with the response status
+// (0 if there was no response). Nil limiter doesn't limit anything
+func (a *adaptiveLimiter) acquire(link string) func(status int) {
+	u, err := url.Parse(link)
+	if a == nil || err != nil || u.Host == "" {
+		return func(int) {}
+	}
+	h := a.host(u.Host)
+	h.mu.Lock()
+	for h.active >= int(h.limit) {
+		h.cond.Wait()
+	}
+	h.active++
+	wait := time.Until(h.next)
+	if wait < 0 {
+		wait = 0
+	}
+	h.next = time.Now().Add(wait + h.delay)
+	h.mu.Unlock()
+	time.Sleep(wait)
+	start := time.Now()
+	return func(status int) {
+		h.mu.Lock()
+		h.active--
+		h.adjust(status, start)
+		h.mu.Unlock()
+		h.cond.Broadcast()
+	}
+}
+
+// Pauses requests of the link's host for d (pause isn't shortened by later calls)
+func (a *adaptiveLimiter) pause(link string, d time.Duration) {
+	u, err := url.Parse(link)
+	if a == nil || err != nil || u.Host == "" {
+		return
+	}
+	h := a.host(u.Host)
+	h.mu.Lock()
+	if until := time.Now().Add(d); until.After(h.next) {
+		h.next = until
+	}
+	h.mu.Unlock()
+}
+
+// Decreases concurrency and rate multiplicatively on overload responses, increases them additively otherwise
+func (h *hostThrottle) adjust(status int, start time.Time) {
+	switch status {
+	case 0:
+		// Connection errors don't tell whether host is overloaded
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		if start.Before(h.reduced) {
+			return
+		}
+		h.reduced = time.Now()
+		if h.limit /= 2; h.limit < 1 {
+			h.limit = 1
+		}
+		if h.delay *= 2; h.delay < minThrottleDelay {
+			h.delay = minThrottleDelay
+		}
+		if h.delay > maxThrottleDelay {
+			h.delay = maxThrottleDelay
+		}
+		if h.delay < h.floor {
+			h.delay = h.floor
+		}
+	default:
+		if h.limit += 1 / h.limit; h.limit > h.max {
+			h.limit = h.max
+		}
+		if h.delay -= h.delay / 10; h.delay < minThrottleDelay/10 {
+			h.delay = 0
+		}
+		if h.delay < h.floor {
+			h.delay = h.floor
+		}
+	}
+}
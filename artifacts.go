@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stores finished reports as <owner>/<repo>/<run>/report.{json,html,md} keys
+// and removes old runs according to retention policy
+type ArtifactStore struct {
+	Dir       string        // local directory, which is used unless Backend is set
+	Backend   Storage       // shared storage backend
+	PublicUrl string        // server's external URL, used for links in notifications
+	KeepRuns  int           // how many runs per repository are kept (0 - unlimited)
+	MaxAge    time.Duration // runs older than that are removed (0 - unlimited)
+
+	mu sync.Mutex
+}
+
+// Rendered report formats and their content types
+var artifactFormats = map[string]string{
+	"json": "application/json",
+	"html": "text/html; charset=utf-8",
+	"md":   "text/markdown; charset=utf-8",
+}
+
+// Returns storage of reports
+func (a *ArtifactStore) storage() Storage {
+	if a.Backend == nil {
+		return &fileStorage{dir: a.Dir}
+	}
+	return a.Backend
+}
+
+// Stores every repository's report as a separate run of profile and sets report's artifact URL
+func (a *ArtifactStore) Store(owner, profile string, reports []*MdReport, elapsed time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	run := time.Now().UTC().Format(runTimeFormat) + "-" + hex.EncodeToString(suffix)
+	for _, md := range reports {
+		if md == nil {
+			continue
+		}
+		runKey := owner + "/" + *md.Repository.Name + "/" + run
+		if err := a.render(runKey, profile, md, elapsed); err != nil {
+			log.Println("[ERR] Couldn't store report "+runKey+":", err)
+			continue
+		}
+		url := strings.TrimSuffix(a.PublicUrl, "/") + "/reports/" + runKey
+		md.ArtifactUrl = &url
+		a.cleanup(owner, *md.Repository.Name)
+	}
+}
+
+// Writes repository's report in all artifact formats and the profile, which the run used. Run exists
+// once its report.json is stored, which is written after the profile and other formats, so readers
+// never see a run without its profile. Partially stored run is removed
+func (a *ArtifactStore) render(runKey, profile string, md *MdReport, elapsed time.Duration) error {
+	reports := []*MdReport{md}
+	var jsonBody, htmlBody, mdBody bytes.Buffer
+	if err := writeJsonReport(reports, &jsonBody, elapsed); err != nil {
+		return err
+	}
+	if err := writeHtmlReport(reports, &htmlBody, elapsed); err != nil {
+		return err
+	}
+	writeRepoReport(md, &mdBody, true)
+
+	names := []string{"profile", "report.html", "report.md"}
+	files := map[string][]byte{"profile": []byte(profile), "report.html": htmlBody.Bytes(), "report.md": mdBody.Bytes(), "report.json": jsonBody.Bytes()}
+	if md.Options != nil && md.Options.SignKey != nil {
+		names = append(names, "report.json.sig")
+		files["report.json.sig"] = signature(jsonBody.Bytes(), md.Options.SignKey)
+	}
+	names = append(names, "report.json")
+
+	store := a.storage()
+	for i, name := range names {
+		if err := store.Put(runKey+"/"+name, files[name]); err != nil {
+			for _, written := range names[:i] {
+				store.Delete(runKey + "/" + written)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Run names are their UTC start time followed by a random suffix, so runs started in the same second
+// don't overwrite each other
+const runTimeFormat = "20060102T150405Z"
+
+// Returns start time of a run
+func runTime(run string) (time.Time, error) {
+	return time.Parse(runTimeFormat, strings.SplitN(run, "-", 2)[0])
+}
+
+// Returns repository's stored runs (having report.json), newest first
+func (a *ArtifactStore) runs(owner, repo string) []string {
+	keys, _ := a.storage().List(owner + "/" + repo + "/")
+	var runs []string
+	for _, key := range keys {
+		parts := strings.Split(key, "/")
+		if len(parts) == 4 && parts[3] == "report.json" {
+			runs = append(runs, parts[2])
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(runs)))
+	return runs
+}
+
+// Returns profile of a stored run. Runs stored without profile used server's default settings
+func (a *ArtifactStore) runProfile(owner, repo, run string) string {
+	profile, _ := a.storage().Get(owner + "/" + repo + "/" + run + "/profile")
+	return string(profile)
+}
+
+// Returns repository's runs, which the key may read, newest first
+func (a *ArtifactStore) allowedRuns(owner, repo string, key *ApiKey) []string {
+	var allowed []string
+	for _, run := range a.runs(owner, repo) {
+		if key.allowsProfile(a.runProfile(owner, repo, run)) {
+			allowed = append(allowed, run)
+		}
+	}
+	return allowed
+}
+
+// Removes runs exceeding retention policy
+func (a *ArtifactStore) cleanup(owner, repo string) {
+	store := a.storage()
+	for i, run := range a.runs(owner, repo) {
+		expired := a.KeepRuns > 0 && i >= a.KeepRuns
+		if started, err := runTime(run); err == nil && a.MaxAge > 0 && time.Since(started) > a.MaxAge {
+			expired = true
+		}
+		if !expired {
+			continue
+		}
+		keys, _ := store.List(owner + "/" + repo + "/" + run + "/")
+		for _, key := range keys {
+			store.Delete(key)
+		}
+	}
+}
+
+// GET /reports/<owner>/<repo> lists stored runs (newest first).
+// GET /reports/<owner>/<repo>/<run|latest>[?format=html|json|md] returns stored report.
+// Runs of other profiles don't exist for profile-scoped keys
+func (a *ArtifactStore) handle(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/reports"), "/"), "/")
+	for _, p := range parts {
+		if p == "" || p == "." || p == ".." {
+			http.NotFound(w, r)
+			return
+		}
+	}
+	if len(parts) < 2 || len(parts) > 3 {
+		http.NotFound(w, r)
+		return
+	}
+	a.mu.Lock()
+	runs := a.allowedRuns(parts[0], parts[1], requestKey(r))
+	a.mu.Unlock()
+
+	if len(parts) == 2 {
+		if runs == nil {
+			runs = []string{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(runs)
+		return
+	}
+	run := parts[2]
+	if run == "latest" {
+		if len(runs) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		run = runs[0]
+	} else if !containsString(runs, run) {
+		http.NotFound(w, r)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "html"
+	}
+	contentType, ok := artifactFormats[format]
+	if !ok {
+		http.Error(w, "unknown format", http.StatusBadRequest)
+		return
+	}
+	content, err := a.storage().Get(parts[0] + "/" + parts[1] + "/" + run + "/report." + format)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(content)
+}
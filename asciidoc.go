@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// link:target[text], image::target[alt] (block) and image:target[alt] (inline) macros
+var asciidocMacro = regexp.MustCompile(`\b(link|image):(:?)(\+\+[^\n]*?\+\+|[^\s\[\]]+)\[([^\]\n]*)\]`)
+
+// Delimiters of listing, literal and comment blocks, which content isn't checked
+var asciidocBlock = regexp.MustCompile(`^(-{4,}|\.{4,}|/{4,})\s*$`)
+
+// :imagesdir: attribute, which image targets are relative to
+var asciidocImagesDir = regexp.MustCompile(`(?m)^:imagesdir:[ \t]*(\S*)[ \t]*$`)
+
+// Returns links of an AsciiDoc document: link and image macros, URL macros (https://...[text]) and
+// bare URLs. Links in listing, literal and comment blocks and in comment lines are skipped
+func asciidocLinks(content []byte) []referenceLink {
+	var links []referenceLink
+	skipped := asciidocSkippedBlocks(content)
+	inBlock := func(offset int) bool {
+		for _, b := range skipped {
+			if offset >= b[0] && offset < b[1] {
+				return true
+			}
+		}
+		return false
+	}
+	imagesDir := ""
+	if m := asciidocImagesDir.FindSubmatch(content); m != nil {
+		imagesDir = string(m[1])
+	}
+	for _, m := range asciidocMacro.FindAllSubmatchIndex(content, -1) {
+		if inBlock(m[0]) {
+			continue
+		}
+		target, text := string(content[m[6]:m[7]]), string(content[m[8]:m[9]])
+		image := string(content[m[2]:m[3]]) == "image"
+		// Passthrough (link:++https://...++[]) keeps special characters of the target
+		target = strings.TrimSuffix(strings.TrimPrefix(target, "++"), "++")
+		if image && imagesDir != "" && !strings.Contains(target, "://") && !strings.HasPrefix(target, "/") {
+			target = path.Join(imagesDir, target)
+		}
+		links = append(links, referenceLink{id: text, target: "[" + text + "](" + target + ")", offset: m[0], end: m[1], image: image})
+	}
+	for _, loc := range bareUrl.FindAllIndex(content, -1) {
+		start := loc[0]
+		if inBlock(start) || isCovered(links, start) {
+			continue
+		}
+		target := trimBareUrl(string(content[start:loc[1]]))
+		text := target
+		end := start + len(target)
+		// URL macro: https://...[text]
+		if rest := content[end:]; bytes.HasPrefix(rest, []byte("[")) {
+			if i := bytes.IndexAny(rest, "]\n"); i > 0 && rest[i] == ']' {
+				if t := string(rest[1:i]); t != "" {
+					text = t
+				}
+				end += i + 1
+			}
+		}
+		links = append(links, referenceLink{id: text, target: "[" + text + "](" + target + ")", offset: start, end: end})
+	}
+	return links
+}
+
+// Returns [start, end) offsets of listing, literal and comment blocks and of comment lines
+func asciidocSkippedBlocks(content []byte) [][2]int {
+	var blocks [][2]int
+	var delimiter string
+	start := -1
+	for offset := 0; offset < len(content); {
+		end := offset + lineEnd(content[offset:])
+		line := strings.TrimRight(string(content[offset:end]), "\r")
+		switch {
+		case start >= 0 && strings.TrimSpace(line) == delimiter:
+			blocks = append(blocks, [2]int{start, end})
+			start = -1
+		case start >= 0:
+		case asciidocBlock.MatchString(line):
+			start, delimiter = offset, strings.TrimSpace(line)
+		case strings.HasPrefix(line, "//"):
+			blocks = append(blocks, [2]int{offset, end})
+		}
+		offset = end + 1
+	}
+	if start >= 0 {
+		blocks = append(blocks, [2]int{start, len(content)})
+	}
+	return blocks
+}
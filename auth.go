@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// API roles. Trigger role includes read permissions
+const (
+	roleRead    = "read"
+	roleTrigger = "trigger"
+)
+
+// Server API key. Key can be stored as plain text or as "sha256:<hex digest>"
+type ApiKey struct {
+	Name     string   `json:"name"`
+	Key      string   `json:"key"`
+	Role     string   `json:"role"`
+	Profiles []string `json:"profiles"` // profiles the key may use (empty - any)
+}
+
+// Returns true if the presented secret matches the key
+func (k *ApiKey) matches(secret string) bool {
+	if strings.HasPrefix(k.Key, "sha256:") {
+		digest := strings.TrimPrefix(k.Key, "sha256:")
+		sum := sha256.Sum256([]byte(secret))
+		return subtle.ConstantTimeCompare([]byte(strings.ToLower(digest)), []byte(hex.EncodeToString(sum[:]))) == 1
+	}
+	return subtle.ConstantTimeCompare([]byte(k.Key), []byte(secret)) == 1
+}
+
+// Returns true if the key may use profile. Nil key (anonymous request) and keys without profiles may use any
+func (k *ApiKey) allowsProfile(profile string) bool {
+	return k == nil || len(k.Profiles) == 0 || containsString(k.Profiles, profile)
+}
+
+// Context key of the API key, which authorized the request
+type apiKeyContext struct{}
+
+// Returns API key, which authorized the request. Nil for anonymous requests
+func requestKey(r *http.Request) *ApiKey {
+	key, _ := r.Context().Value(apiKeyContext{}).(*ApiKey)
+	return key
+}
+
+// Returns true if role grants required permission
+func roleAllows(role, required string) bool {
+	return role == roleTrigger || role == required
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
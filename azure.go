@@ -0,0 +1,145 @@
+//go:build !noproviders && !minimal
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+func init() {
+	registerProvider("azure", provider{getAzureRepos, setAzureUrls, false, azureNamedRef})
+}
+
+// Azure DevOps Git repository
+type AzureRepository struct {
+	Id            string `json:"id"`
+	Name          string `json:"name"`
+	WebUrl        string `json:"webUrl"`
+	DefaultBranch string `json:"defaultBranch"` // refs/heads/<branch>, empty for empty repositories
+	Size          int    `json:"size"`
+	IsDisabled    bool   `json:"isDisabled"`
+	IsFork        bool   `json:"isFork"`
+}
+
+// Converts Azure DevOps repository to a common one
+func (a *AzureRepository) repository(api string) *Repository {
+	fork := a.IsFork
+	archived := false
+	branch := strings.TrimPrefix(a.DefaultBranch, "refs/heads/")
+	apiUrl := api + "/git/repositories/" + a.Id
+	return &Repository{
+		URL:           &apiUrl,
+		Name:          &a.Name,
+		Fork:          &fork,
+		Disabled:      &a.IsDisabled,
+		Archived:      &archived,
+		HTMLURL:       &a.WebUrl,
+		DefaultBranch: &branch,
+		Size:          &a.Size,
+		FlatArchive:   true,
+	}
+}
+
+// Sets Azure DevOps archive and web URLs. Files and directories are opened as
+// <repo web URL>?version=GB<branch>&path=<path> (GC<sha> for commits), archive is downloaded using Items API
+func setAzureUrls(r *Repository) {
+	versionType, version := "branch", "GB"
+	if r.RefCommit {
+		versionType, version = "commit", "GC"
+	}
+	archiveUrl := *r.URL + "/items?scopePath=/&recursionLevel=full&download=true&$format=zip" +
+		"&versionDescriptor.versionType=" + versionType + "&versionDescriptor.version=" + url.QueryEscape(r.ref()) + "&api-version=7.0"
+	webUrl := *r.HTMLURL + "?version=" + version + url.QueryEscape(r.ref()) + "&path="
+	r.ArchiveUrl, r.WebUrl, r.TreeUrl = &archiveUrl, &webUrl, &webUrl
+}
+
+// Returns true if Azure DevOps repository has a branch or tag named ref. Refs API filters by prefix,
+// so names are compared exactly
+func azureNamedRef(r *Repository, ref string, opts *Options) bool {
+	if r.URL == nil {
+		return false
+	}
+	for _, kind := range []string{"heads/", "tags/"} {
+		resp, err := providerGet(*r.URL+"/refs?filter="+url.QueryEscape(kind+ref)+"&api-version=7.0", opts)
+		if err != nil {
+			continue
+		}
+		var refs struct {
+			Value []struct {
+				Name string `json:"name"`
+			} `json:"value"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&refs)
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK {
+			continue
+		}
+		for _, v := range refs.Value {
+			if v.Name == "refs/"+kind+ref {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Returns not-empty Git repositories of Azure DevOps project (disabled and forked only if options allow). Account is
+// specified as <organization>/<project>, token is a personal access token (PAT)
+func getAzureRepos(account, repo string, opts *Options) ([]*Repository, error) {
+	var outRepos []*Repository
+	if !strings.Contains(account, "/") {
+		return nil, errors.New("[ERR] Azure DevOps account must be specified as <organization>/<project>")
+	}
+	base := "https://dev.azure.com"
+	if opts.ProviderUrl != "" {
+		base = strings.TrimSuffix(opts.ProviderUrl, "/")
+	}
+	api := base + "/" + account + "/_apis"
+
+	if repo != "" {
+		resp, err := providerGet(api+"/git/repositories/"+url.PathEscape(repo)+"?api-version=7.0", opts)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, nil
+		}
+		var a AzureRepository
+		if err := json.NewDecoder(resp.Body).Decode(&a); err != nil {
+			return nil, err
+		}
+		if a.DefaultBranch != "" {
+			outRepos = append(outRepos, a.repository(api))
+		}
+		return outRepos, nil
+	}
+
+	resp, err := providerGet(api+"/git/repositories?api-version=7.0", opts)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("[ERR] Couldn't list %s repositories: %s", account, resp.Status)
+	}
+	var list struct {
+		Value []AzureRepository `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+	for i := range list.Value {
+		if a := &list.Value[i]; a.DefaultBranch != "" {
+			if r := a.repository(api); opts.includes(r) {
+				outRepos = append(outRepos, r)
+			}
+		}
+	}
+	return outRepos, nil
+}
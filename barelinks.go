@@ -0,0 +1,62 @@
+package main
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Plain URL in text or autolink (<https://...>)
+var bareUrl = regexp.MustCompile("https?://[^\\s<>\\[\\]\"'`]+")
+
+// Trims trailing punctuation of a bare URL. Closing parentheses are kept while they are
+// balanced, so Wikipedia-style URLs (https://en.wikipedia.org/wiki/Go_(programming_language)) stay whole
+func trimBareUrl(target string) string {
+	for {
+		trimmed := strings.TrimRight(target, ".,;:!?*_~")
+		if strings.HasSuffix(trimmed, ")") && strings.Count(trimmed, ")") > strings.Count(trimmed, "(") {
+			trimmed = trimmed[:len(trimmed)-1]
+		}
+		if trimmed == target {
+			return target
+		}
+		target = trimmed
+	}
+}
+
+// Returns true if offset is inside any of the links
+func isCovered(links []referenceLink, offset int) bool {
+	for _, l := range links {
+		if offset >= l.offset && offset < l.end {
+			return true
+		}
+	}
+	return false
+}
+
+// <a href="..."> and <img src="..."> tags
+var htmlLinkTag = regexp.MustCompile(`(?is)<(a|img)\s[^>]*?\b(href|src)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+
+// Returns URLs of HTML anchor and image tags of raw HTML spans. Empty and javascript: URLs are skipped
+func htmlLinks(content []byte, spans [][2]int) []referenceLink {
+	var links []referenceLink
+	for _, span := range spans {
+		for _, m := range htmlLinkTag.FindAllSubmatchIndex(content[span[0]:span[1]], -1) {
+			tag, attr := strings.ToLower(string(content[span[0]+m[2]:span[0]+m[3]])), strings.ToLower(string(content[span[0]+m[4]:span[0]+m[5]]))
+			if (tag == "a") != (attr == "href") {
+				continue
+			}
+			var target string
+			for i := 6; i < len(m); i += 2 {
+				if m[i] >= 0 {
+					target = strings.TrimSpace(html.UnescapeString(string(content[span[0]+m[i] : span[0]+m[i+1]])))
+				}
+			}
+			if target == "" || strings.HasPrefix(strings.ToLower(target), "javascript:") {
+				continue
+			}
+			links = append(links, referenceLink{id: tag, target: target, offset: span[0] + m[0], end: span[0] + m[1], image: tag == "img"})
+		}
+	}
+	return links
+}
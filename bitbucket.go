@@ -0,0 +1,114 @@
+//go:build !noproviders && !minimal
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+func init() {
+	registerProvider("bitbucket", provider{getBitbucketRepos, setBitbucketUrls, false, nil})
+}
+
+// Bitbucket Cloud 2.0 API repository
+type BitbucketRepository struct {
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	IsPrivate bool   `json:"is_private"`
+	Size      int    `json:"size"`
+	Parent    *struct {
+		FullName string `json:"full_name"`
+	} `json:"parent"`
+	MainBranch *struct {
+		Name string `json:"name"`
+	} `json:"mainbranch"`
+	Links struct {
+		Html struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+// Page of Bitbucket API repository list
+type BitbucketPage struct {
+	Values []BitbucketRepository `json:"values"`
+	Next   string                `json:"next"`
+}
+
+// Converts Bitbucket repository to a common one
+func (b *BitbucketRepository) repository() *Repository {
+	fork := b.Parent != nil
+	disabled, archived := false, false
+	htmlUrl := b.Links.Html.Href
+	branch := b.MainBranch.Name
+	return &Repository{
+		Name:          &b.Slug,
+		Fork:          &fork,
+		Disabled:      &disabled,
+		Archived:      &archived,
+		HTMLURL:       &htmlUrl,
+		DefaultBranch: &branch,
+		Size:          &b.Size,
+	}
+}
+
+// Sets Bitbucket's archive and web URLs. Files and directories have the same web URL (/src/<ref>/<path>)
+func setBitbucketUrls(r *Repository) {
+	archiveUrl := *r.HTMLURL + "/get/" + r.ref() + ".zip"
+	webUrl := *r.HTMLURL + "/src/" + r.ref()
+	r.ArchiveUrl, r.WebUrl, r.TreeUrl = &archiveUrl, &webUrl, &webUrl
+}
+
+// Returns public/not-empty repositories of Bitbucket workspace
+func getBitbucketRepos(workspace, repo string, opts *Options) ([]*Repository, error) {
+	var outRepos []*Repository
+
+	if repo != "" {
+		resp, err := providerGet("https://api.bitbucket.org/2.0/repositories/"+workspace+"/"+repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, nil
+		}
+		var b BitbucketRepository
+		if err := json.NewDecoder(resp.Body).Decode(&b); err != nil {
+			return nil, err
+		}
+		if b.MainBranch != nil {
+			outRepos = append(outRepos, b.repository())
+		}
+		return outRepos, nil
+	}
+
+	next := "https://api.bitbucket.org/2.0/repositories/" + workspace + "?pagelen=100"
+	for next != "" && !opts.enoughRepos(outRepos) {
+		resp, err := providerGet(next, opts)
+		if err != nil {
+			return nil, err
+		}
+		var page BitbucketPage
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("[ERR] Couldn't list %s workspace repositories: %s", workspace, resp.Status)
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Empty repositories have no main branch
+		for i := range page.Values {
+			b := &page.Values[i]
+			if !b.IsPrivate && b.MainBranch != nil {
+				if r := b.repository(); opts.includes(r) {
+					outRepos = append(outRepos, r)
+				}
+			}
+		}
+		next = page.Next
+	}
+	return outRepos, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Author of the last change of a line (git blame)
+type BlameInfo struct {
+	Author *string
+	Email  *string
+	Commit *string
+	Date   *time.Time
+}
+
+// Returns short description of a blamed change
+func (b *BlameInfo) String() string {
+	return "last changed by " + *b.Author + " on " + b.Date.Format("2006-01-02") + " in " + *b.Commit
+}
+
+// Runs git blame on a line of a file in local git working tree. Returns nil if directory is not
+// a git repository or the line wasn't committed yet
+func blameLine(root, file string, line int) *BlameInfo {
+	l := strconv.Itoa(line)
+	out, err := exec.Command("git", "-C", root, "blame", "--porcelain", "-L", l+","+l, "--", file).Output()
+	if err != nil {
+		return nil
+	}
+	var b BlameInfo
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for first := true; scanner.Scan(); first = false {
+		key, value, _ := strings.Cut(scanner.Text(), " ")
+		switch {
+		case first:
+			if strings.Trim(key, "0") == "" {
+				return nil
+			}
+			commit := key[:7]
+			b.Commit = &commit
+		case key == "author":
+			b.Author = &value
+		case key == "author-mail":
+			email := strings.Trim(value, "<>")
+			b.Email = &email
+		case key == "author-time":
+			sec, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil
+			}
+			date := time.Unix(sec, 0).UTC()
+			b.Date = &date
+		}
+	}
+	if b.Commit == nil || b.Author == nil || b.Date == nil {
+		return nil
+	}
+	return &b
+}
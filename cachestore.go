@@ -0,0 +1,105 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// Default time, which verified links are skipped for
+const defaultCacheTtl = 24 * time.Hour
+
+// Verified link, stored as cache/<sha> key of the storage
+type storedResult struct {
+	URL         string     `json:"url"`
+	StatusCode  int        `json:"status_code"`
+	ContentType string     `json:"content_type,omitempty"`
+	Redirects   []Redirect `json:"redirects,omitempty"`
+	Checked     time.Time  `json:"checked"`
+}
+
+// Results of verified links, which persist between runs. Links verified within TTL aren't
+// requested again, failed links are always rechecked. Shared storage (redis://) shares
+// verdicts between CI runners and server replicas
+type linkStore struct {
+	storage Storage
+	ttl     time.Duration
+}
+
+// Returns cache of --cache location: storage URL (see --storage) or local directory ("~/" is expanded).
+// Nil if location is empty
+func newLinkStore(location string, ttl time.Duration) (*linkStore, error) {
+	if location == "" {
+		return nil, nil
+	}
+	if strings.Contains(location, "://") {
+		storage, err := openStorage(location)
+		if err != nil {
+			return nil, err
+		}
+		return &linkStore{storage: storage, ttl: ttl}, nil
+	}
+	if strings.HasPrefix(location, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		location = filepath.Join(home, location[2:])
+	}
+	return &linkStore{storage: &fileStorage{dir: location}, ttl: ttl}, nil
+}
+
+// Returns storage key of normalized URL's result
+func linkStoreKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "cache/" + hex.EncodeToString(sum[:16])
+}
+
+// Returns response of a link, which was verified within TTL
+func (s *linkStore) load(key string) (*req.Response, bool) {
+	if s == nil {
+		return nil, false
+	}
+	content, err := s.storage.Get(linkStoreKey(key))
+	if err != nil {
+		return nil, false
+	}
+	var stored storedResult
+	if json.Unmarshal(content, &stored) != nil || stored.URL != key || time.Since(stored.Checked) > s.ttl {
+		return nil, false
+	}
+	resp := &http.Response{StatusCode: stored.StatusCode, Header: http.Header{}}
+	if stored.ContentType != "" {
+		resp.Header.Set("Content-Type", stored.ContentType)
+	}
+	// Followed requests are rebuilt, so the redirect chain is reported the same way
+	if request, err := http.NewRequest(http.MethodGet, stored.URL, nil); err == nil {
+		for _, redirect := range stored.Redirects {
+			next, err := http.NewRequest(http.MethodGet, redirect.Location, nil)
+			if err != nil {
+				break
+			}
+			next.Response = &http.Response{StatusCode: redirect.Status, Request: request}
+			request = next
+		}
+		resp.Request = request
+	}
+	return &req.Response{Response: resp}, true
+}
+
+// Stores verified link. Storage failures only cost a recheck, so they're ignored
+func (s *linkStore) store(key string, r *req.Response) {
+	if s == nil || r == nil || r.Response == nil {
+		return
+	}
+	stored := storedResult{URL: key, StatusCode: r.StatusCode, ContentType: r.Header.Get("Content-Type"), Redirects: redirectChain(r), Checked: time.Now().UTC()}
+	content, _ := json.MarshalIndent(stored, "", "  ")
+	s.storage.Put(linkStoreKey(key), content)
+}
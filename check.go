@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Checks links in a single markdown file. Relative links are resolved against base URL (if set),
+// otherwise they're validated against local root directory (file's directory by default)
+func runFileCheck(opts *Options, outputs []*ReportOutput) ([]*MdReport, error) {
+	var mu sync.Mutex
+	file, err := filepath.Abs(opts.File)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(file); err != nil || info.IsDir() {
+		return nil, codedError(codeInvalidPath, opts.File+" is not a file")
+	}
+	root := filepath.Dir(file)
+	if opts.LocalPath != "" {
+		if root, err = filepath.Abs(opts.LocalPath); err != nil {
+			return nil, err
+		}
+	}
+	rel, err := filepath.Rel(root, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil, codedError(codeInvalidPath, opts.File+" is outside of "+root+" directory")
+	}
+	rel = filepath.ToSlash(rel)
+	name := path.Base(rel)
+	allLinksDefVal := true
+	md := &MdReport{Repository: &Repository{Name: &name}, LocalPath: &root, File: &rel, AllLinksOK: &allLinksDefVal, Options: opts}
+	if opts.BaseUrl != "" {
+		base := strings.TrimSuffix(opts.BaseUrl, "/")
+		md.Repository.WebUrl, md.Repository.TreeUrl = &base, &base
+	}
+	checkMdFiles(md, &mu, outputs)
+	return []*MdReport{md}, nil
+}
+
+// Returns 'check' command, which validates links of a single markdown file
+func checkCommand(reportFileName *string, opts *Options) *cli.Command {
+	return &cli.Command{
+		Name:      "check",
+		Usage:     "Check links in a single markdown file",
+		ArgsUsage: "FILE",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "root",
+				Usage: "Local directory which relative links are validated against (default: file's directory)",
+			},
+			&cli.StringFlag{
+				Name:  "base-url",
+				Usage: "URL which relative links are resolved against (e.g. https://github.com/<owner>/<repo>/blob/main)",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Args().First() == "" {
+				return cli.Exit("[ERR] Markdown file is not specified", 1)
+			}
+			opts.File = c.Args().First()
+			if c.IsSet("root") {
+				opts.LocalPath = c.String("root")
+			}
+			if c.IsSet("base-url") {
+				opts.BaseUrl = c.String("base-url")
+			}
+			start := time.Now()
+			reports, err := checkAndReport("", "", *reportFileName, opts)
+			if err != nil {
+				return err
+			}
+			return exitSummary(reports, time.Since(start), opts)
+		},
+	}
+}
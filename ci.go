@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Repository checked by a CI system, inferred from its environment variables
+type ciEnvironment struct {
+	Name       string   // CI system
+	Repository string   // <owner>/<repo> (GitLab projects might have subgroups)
+	Url        string   // repository's web URL
+	Ref        string   // checked out commit
+	Workspace  string   // checkout directory
+	Outputs    []string // native output formats of the CI system
+}
+
+// Detects GitHub Actions, GitLab CI or Azure Pipelines. Returns nil outside of CI
+func detectCi() *ciEnvironment {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		server := os.Getenv("GITHUB_SERVER_URL")
+		if server == "" {
+			server = "https://github.com"
+		}
+		return &ciEnvironment{
+			Name:       "GitHub Actions",
+			Repository: os.Getenv("GITHUB_REPOSITORY"),
+			Url:        server + "/" + os.Getenv("GITHUB_REPOSITORY"),
+			Ref:        os.Getenv("GITHUB_SHA"),
+			Workspace:  os.Getenv("GITHUB_WORKSPACE"),
+			Outputs:    []string{"cli", "github"},
+		}
+	case os.Getenv("GITLAB_CI") == "true":
+		return &ciEnvironment{
+			Name:       "GitLab CI",
+			Repository: os.Getenv("CI_PROJECT_PATH"),
+			Url:        os.Getenv("CI_PROJECT_URL"),
+			Ref:        os.Getenv("CI_COMMIT_SHA"),
+			Workspace:  os.Getenv("CI_PROJECT_DIR"),
+			Outputs:    []string{"cli", "junit=gmuv-junit.xml"},
+		}
+	case strings.EqualFold(os.Getenv("TF_BUILD"), "true"):
+		return &ciEnvironment{
+			Name:       "Azure Pipelines",
+			Repository: os.Getenv("BUILD_REPOSITORY_NAME"),
+			Url:        os.Getenv("BUILD_REPOSITORY_URI"),
+			Ref:        os.Getenv("BUILD_SOURCEVERSION"),
+			Workspace:  os.Getenv("BUILD_SOURCESDIRECTORY"),
+			Outputs:    []string{"cli", "azure"},
+		}
+	}
+	return nil
+}
+
+// Returns repository of the checkout. Relative links are still validated against the checkout
+func (ci *ciEnvironment) repository() *Repository {
+	r := &Repository{}
+	if ci.Repository != "" {
+		name := path.Base(ci.Repository)
+		r.Name = &name
+	}
+	if ci.Url != "" {
+		r.HTMLURL = &ci.Url
+	}
+	if ci.Ref != "" {
+		r.Ref = &ci.Ref
+	}
+	return r
+}
+
+// Returns "ci" command, which checks current checkout using CI system's environment
+func ciCommand(reportFileName *string, opts *Options) *cli.Command {
+	return &cli.Command{
+		Name:  "ci",
+		Usage: "Check current checkout in GitHub Actions, GitLab CI or Azure Pipelines (repository, ref and output are inferred)",
+		Action: func(c *cli.Context) error {
+			ci := detectCi()
+			if ci == nil {
+				return cli.Exit("[ERR] CI system wasn't detected (GitHub Actions, GitLab CI or Azure Pipelines)", 1)
+			}
+			opts.ci = ci
+			opts.LocalPath = ci.Workspace
+			if opts.LocalPath == "" {
+				opts.LocalPath = "."
+			}
+			if !c.IsSet("output") {
+				opts.Outputs = ci.Outputs
+			}
+			start := time.Now()
+			reports, err := checkAndReport("", "", *reportFileName, opts)
+			if err != nil {
+				return err
+			}
+			if err := exitSummary(reports, time.Since(start), opts); err != nil {
+				return err
+			}
+			for _, md := range reports {
+				if (md.AllLinksOK != nil && !*md.AllLinksOK) || (md.State != nil && strings.HasPrefix(*md.State, "[ERR]")) {
+					return cli.Exit("[ERR] Broken links were found", 1)
+				}
+			}
+			return nil
+		},
+	}
+}
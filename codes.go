@@ -0,0 +1,220 @@
+package main
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Stable codes of findings (GMUV0xx) and execution errors (GMUV1xx). Codes are never reused or renumbered
+const (
+	codeRateLimited      = "GMUV001"
+	codeDnsFailure       = "GMUV002"
+	codeTimeout          = "GMUV003"
+	codeTlsError         = "GMUV004"
+	codeConnectionFailed = "GMUV005"
+	codeNotFound         = "GMUV006"
+	codeGone             = "GMUV007"
+	codeAccessDenied     = "GMUV008"
+	codeServerError      = "GMUV009"
+	codeHttpError        = "GMUV010"
+	codeMissingFile      = "GMUV011"
+	codeMissingAnchor    = "GMUV012"
+	codeEgressDenied     = "GMUV013"
+	codeMovedPermanently = "GMUV014"
+	codeRequestFailed    = "GMUV015"
+	codeTlsProtocol      = "GMUV016"
+	codeTlsSni           = "GMUV017"
+	codeTlsHandshake     = "GMUV018"
+	codeNotImage         = "GMUV019"
+	codePlaceholder      = "GMUV020"
+	codeUnknownScheme    = "GMUV021"
+	codePrivateTarget    = "GMUV022"
+	codeInvalidEmail     = "GMUV023"
+	codeMailDomain       = "GMUV024"
+	codeCaseMismatch     = "GMUV025"
+	codeSymlinkLoop      = "GMUV026"
+	codeDanglingSymlink  = "GMUV027"
+	codeOutsideSymlink   = "GMUV028"
+	codeCaseDuplicate    = "GMUV029"
+	codeTranscoded       = "GMUV030"
+	codeUndefinedRef     = "GMUV031"
+	codeOrphaned         = "GMUV032"
+	codeCrossVersion     = "GMUV033"
+	codeMissingInLatest  = "GMUV034"
+
+	codeListFailed     = "GMUV101"
+	codeDownloadFailed = "GMUV102"
+	codeArchiveInvalid = "GMUV103"
+	codeReadFailed     = "GMUV104"
+	codeWorkDirFailed  = "GMUV105"
+	codeCloneFailed    = "GMUV106"
+	codeInvalidPath    = "GMUV107"
+)
+
+// Error catalogue entry
+type errorCode struct {
+	Code        string
+	Name        string
+	Description string
+}
+
+// All codes, which might be emitted in reports
+var errorCatalogue = []errorCode{
+	{codeRateLimited, "ratelimited", "Server rate limited the check (HTTP 429)"},
+	{codeDnsFailure, "dns-failure", "Link's domain couldn't be resolved"},
+	{codeTimeout, "timeout", "Server didn't respond in time"},
+	{codeTlsError, "tls-error", "Server's TLS certificate is not valid"},
+	{codeConnectionFailed, "connection-failed", "Connection was refused or reset"},
+	{codeNotFound, "not-found", "Page doesn't exist (HTTP 404)"},
+	{codeGone, "gone", "Page was removed permanently (HTTP 410)"},
+	{codeAccessDenied, "access-denied", "Page requires authentication or blocks automated requests (HTTP 401/403)"},
+	{codeServerError, "server-error", "Server failed to respond (HTTP 5xx)"},
+	{codeHttpError, "http-error", "Unexpected HTTP status"},
+	{codeMissingFile, "missing-file", "Relative link points to a file or directory, which doesn't exist in the repository"},
+	{codeMissingAnchor, "missing-anchor", "Document has no heading or HTML anchor with link's fragment"},
+	{codeEgressDenied, "egress-denied", "Link is not allowed by the egress policy"},
+	{codeMovedPermanently, "moved-permanently", "Link was moved permanently (HTTP 301/308)"},
+	{codeRequestFailed, "request-failed", "Request failed without HTTP response"},
+	{codeTlsProtocol, "tls-protocol", "Client and server have no TLS version or cipher suite in common"},
+	{codeTlsSni, "tls-sni", "Server doesn't know the host name (SNI) or its certificate is issued for another host"},
+	{codeTlsHandshake, "tls-handshake", "TLS handshake failed"},
+	{codeNotImage, "not-image", "Image link responds with a non-image Content-Type, e.g. an HTML error page"},
+	{codePlaceholder, "placeholder", "Link is a placeholder (example domain or template variable), reported in strict mode"},
+	{codeUnknownScheme, "unknown-scheme", "Link has a URL scheme, which is neither checked nor skipped"},
+	{codePrivateTarget, "private-target", "Public docs link to a private GitHub repository (HTTP 404 without access)"},
+	{codeInvalidEmail, "invalid-email", "mailto: address is not valid (checked with --verify-mailto)"},
+	{codeMailDomain, "dead-mail-domain", "mailto: address domain has no mail exchanger (checked with --verify-mailto)"},
+	{codeCaseMismatch, "case-mismatch", "Relative link differs in case from the target file, which breaks on case-sensitive hosting (warning)"},
+	{codeSymlinkLoop, "symlink-loop", "Symlink chain never reaches a file (repository warning)"},
+	{codeDanglingSymlink, "dangling-symlink", "Symlink points to a path, which doesn't exist (repository warning)"},
+	{codeOutsideSymlink, "outside-symlink", "Symlink points outside of the repository (repository warning)"},
+	{codeCaseDuplicate, "case-duplicate", "Paths differ only by case, only one of them survives a case-insensitive checkout (repository warning)"},
+	{codeTranscoded, "transcoded", "File isn't UTF-8 encoded, its links were checked after conversion (repository warning)"},
+	{codeUndefinedRef, "undefined-reference", "Reference-style link has no definition, so it renders as plain text (repository warning)"},
+	{codeOrphaned, "orphaned", "No other document of the repository links to the document (repository warning, checked with --orphans)"},
+	{codeCrossVersion, "cross-version-link", "Relative link of versioned docs points into another version (repository warning, checked with --versioned-docs)"},
+	{codeMissingInLatest, "missing-in-latest", "Document linked by an older docs version is missing in the latest version (repository warning, checked with --versioned-docs)"},
+	{codeListFailed, "list-failed", "Repository list couldn't be loaded"},
+	{codeDownloadFailed, "download-failed", "Repository archive couldn't be downloaded"},
+	{codeArchiveInvalid, "archive-invalid", "Repository archive couldn't be opened"},
+	{codeReadFailed, "read-failed", "File or directory couldn't be read"},
+	{codeWorkDirFailed, "workdir-failed", "Work directory isn't writable"},
+	{codeCloneFailed, "clone-failed", "Git repository couldn't be cloned"},
+	{codeInvalidPath, "invalid-path", "Specified file or directory doesn't exist"},
+}
+
+// Returns catalogue entry of a code
+func lookupErrorCode(code string) errorCode {
+	for _, c := range errorCatalogue {
+		if c.Code == code {
+			return c
+		}
+	}
+	return errorCode{Code: code}
+}
+
+// Classifies failed link check. repoPath is set if link points to a repository file
+func linkErrorCode(status int, repoPath string, err error) string {
+	var dnsErr *net.DNSError
+	var certErr x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return codeDnsFailure
+	case errors.As(err, &hostErr):
+		return codeTlsSni
+	case errors.As(err, &certErr), errors.As(err, &invalidErr):
+		return codeTlsError
+	case tlsFailure(err) != "":
+		return tlsFailure(err)
+	case err != nil && strings.Contains(err.Error(), "blocked by egress policy"):
+		return codeEgressDenied
+	case err != nil && isTimeout(err):
+		return codeTimeout
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET):
+		return codeConnectionFailed
+	case err != nil:
+		return codeRequestFailed
+	}
+	switch {
+	case status == http.StatusNotFound && repoPath != "":
+		return codeMissingFile
+	case status == http.StatusNotFound:
+		return codeNotFound
+	case status == http.StatusGone:
+		return codeGone
+	case status == http.StatusTooManyRequests:
+		return codeRateLimited
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return codeAccessDenied
+	case status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect:
+		return codeMovedPermanently
+	case status >= 500:
+		return codeServerError
+	case status == 0:
+		return codeRequestFailed
+	}
+	return codeHttpError
+}
+
+// Classifies TLS handshake failure by its alert. Returns empty string if error isn't a handshake failure
+func tlsFailure(err error) string {
+	if err == nil {
+		return ""
+	}
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "protocol version not supported"), strings.Contains(msg, "no supported versions"),
+		strings.Contains(msg, "insufficient security level"), strings.Contains(msg, "no cipher suite supported"):
+		return codeTlsProtocol
+	case strings.Contains(msg, "unrecognized name"):
+		return codeTlsSni
+	case strings.Contains(msg, "handshake failure"), strings.Contains(msg, "remote error: tls:"),
+		strings.Contains(msg, "does not look like a TLS handshake"), strings.Contains(msg, "HTTP response to HTTPS client"),
+		strings.Contains(msg, "tls: "):
+		return codeTlsHandshake
+	}
+	return ""
+}
+
+// Stores repository's execution error, prefixed by its code
+func (md *MdReport) setError(code, s string) {
+	md.ErrorCodes = append(md.ErrorCodes, code)
+	md.setState("[ERR] " + code + " " + s)
+}
+
+// Appends execution error to repository's check state
+func (md *MdReport) addError(code, s string) {
+	md.mu.Lock()
+	md.ErrorCodes = append(md.ErrorCodes, code)
+	md.mu.Unlock()
+	md.addState(" [ERR] " + code + " " + s)
+}
+
+// Returns execution error prefixed by its code
+func codedError(code, s string) error {
+	return errors.New("[ERR] " + code + " " + s)
+}
+
+// Returns "codes" command, which prints error catalogue
+func codesCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "codes",
+		Usage: "List error codes, which are emitted in reports",
+		Action: func(c *cli.Context) error {
+			for _, e := range errorCatalogue {
+				fmt.Printf("%s %-18s %s\n", e.Code, e.Name, e.Description)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Loads flag values from JSON file (keys are flag names). Values are applied only to flags,
+// which weren't set from the command line or environment variables. Values of the selected
+// run profile ("run-profiles": {"<name>": {...}}) take precedence over top-level ones
+func applyConfigFile(c *cli.Context, filename, profile string, flags []cli.Flag) error {
+	var values map[string]interface{}
+
+	if filename == "" {
+		if profile != "" {
+			return fmt.Errorf("[ERR] Run profile %s needs a config file (--config)", profile)
+		}
+		return nil
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("[ERR] Couldn't load %s config: %w", filename, err)
+	}
+	if err := json.Unmarshal(content, &values); err != nil {
+		return fmt.Errorf("[ERR] Couldn't parse %s config: %w", filename, err)
+	}
+	if profile != "" {
+		profiles, _ := values["run-profiles"].(map[string]interface{})
+		profileValues, ok := profiles[profile].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("[ERR] Run profile %s isn't defined in %s config", profile, filename)
+		}
+		for name, value := range profileValues {
+			values[name] = value
+		}
+	}
+	for _, flag := range flags {
+		name := flag.Names()[0]
+		value, ok := values[name]
+		if !ok || c.IsSet(name) {
+			continue
+		}
+		if err := c.Set(name, configValue(value)); err != nil {
+			return fmt.Errorf("[ERR] Couldn't apply %s value from %s config: %w", name, filename, err)
+		}
+	}
+	return nil
+}
+
+// Converts JSON value to a flag's string representation. Lists are joined with commas
+func configValue(value interface{}) string {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, len(v))
+		for i := range v {
+			items[i] = configValue(v[i])
+		}
+		return strings.Join(items, ",")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Named set of settings, which isolates a team sharing one server deployment
+type Profile struct {
+	Token        string   `json:"token"`
+	Provider     string   `json:"provider"`
+	ProviderUrl  string   `json:"provider-url"`
+	Notify       []string `json:"notify"`      // URLs, which receive JSON report of every finished check
+	ReportsDir   string   `json:"reports-dir"` // where scheduled reports are stored
+	GithubCompat bool     `json:"github-compat"`
+	PathStyle    string   `json:"path-style"`
+}
+
+// Scheduled check of an account using a specific profile
+type ScheduleEntry struct {
+	Profile    string `json:"profile"`
+	Username   string `json:"username"`
+	Repository string `json:"repository"`
+	Filename   string `json:"filename"`
+	Every      string `json:"every"`
+}
+
+// Server mode part of the config file
+type ServerConfig struct {
+	Profiles  map[string]*Profile `json:"profiles"`
+	Schedules []ScheduleEntry     `json:"schedules"`
+	ApiKeys   []ApiKey            `json:"api-keys"`
+}
+
+// Loads profiles and schedules from JSON config file
+func loadServerConfig(filename string) (*ServerConfig, error) {
+	config := new(ServerConfig)
+	if filename == "" {
+		return config, nil
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("[ERR] Couldn't load %s config: %w", filename, err)
+	}
+	if err := json.Unmarshal(content, config); err != nil {
+		return nil, fmt.Errorf("[ERR] Couldn't parse %s config: %w", filename, err)
+	}
+	for name, p := range config.Profiles {
+		if _, ok := providers[p.Provider]; p.Provider != "" && !ok {
+			return nil, fmt.Errorf("[ERR] Profile %s uses unsupported provider %s", name, p.Provider)
+		}
+	}
+	for _, key := range config.ApiKeys {
+		if key.Role != roleRead && key.Role != roleTrigger {
+			return nil, fmt.Errorf("[ERR] API key %s has unknown role %s", key.Name, key.Role)
+		}
+	}
+	for _, entry := range config.Schedules {
+		every, err := time.ParseDuration(entry.Every)
+		if err != nil {
+			return nil, fmt.Errorf("[ERR] Schedule of %s has invalid interval: %w", entry.Username, err)
+		}
+		if every < minScheduleInterval {
+			return nil, fmt.Errorf("[ERR] Schedule of %s runs every %s, the interval must be at least %s", entry.Username, every, minScheduleInterval)
+		}
+		if _, ok := config.Profiles[entry.Profile]; entry.Profile != "" && !ok {
+			return nil, fmt.Errorf("[ERR] Schedule of %s uses unknown profile %s", entry.Username, entry.Profile)
+		}
+	}
+	return config, nil
+}
+
+// Shortest interval of scheduled checks, so a misconfigured schedule can't flood providers (or stop the ticker)
+const minScheduleInterval = time.Minute
+
+// Returns a copy of options with profile's settings applied
+func (p *Profile) apply(opts Options) Options {
+	if p == nil {
+		return opts
+	}
+	if p.Provider != "" {
+		opts.Provider, opts.ProviderUrl = p.Provider, p.ProviderUrl
+	}
+	// Profile's token belongs to profile's provider
+	if opts.Provider == "" || opts.Provider == "github" {
+		opts.Token = p.Token
+	} else {
+		opts.Token, opts.ProviderToken = "", p.Token
+	}
+	if p.GithubCompat {
+		opts.GithubCompat = true
+	}
+	if p.PathStyle != "" {
+		opts.PathStyle = p.PathStyle
+	}
+	return opts
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Link context classes
+const (
+	contextProse    = "prose"
+	contextBadge    = "badge"
+	contextTable    = "table"
+	contextFootnote = "footnote"
+	contextHeading  = "heading"
+)
+
+// Severities of a failed link check
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+	severityIgnore  = "ignore" // link isn't checked
+)
+
+// Markdown links and images. Destination may contain balanced parentheses (Wikipedia-style URLs)
+var inlineLinks = regexp.MustCompile(`!?\[[^\[\]]*?\]\((?:[^()]|\([^()]*\))*\)`)
+
+// Classifies link by the line it's found on: footnote definition, heading, table row,
+// badge (image on a line, which contains only images and links) or prose
+func linkContext(content []byte, offset int) string {
+	start := bytes.LastIndexAny(content[:offset], "\r\n") + 1
+	end := bytes.IndexAny(content[offset:], "\r\n")
+	if end < 0 {
+		end = len(content)
+	} else {
+		end += offset
+	}
+	line := bytes.TrimSpace(content[start:end])
+	switch {
+	case bytes.HasPrefix(line, []byte("[^")):
+		return contextFootnote
+	case bytes.HasPrefix(line, []byte("#")):
+		return contextHeading
+	case bytes.HasPrefix(line, []byte("|")):
+		return contextTable
+	case offset > 0 && content[offset-1] == '!' && len(bytes.TrimSpace(stripLinks(line))) == 0:
+		return contextBadge
+	}
+	return contextProse
+}
+
+// Removes links and images from text. Links wrapping images ([![alt](img)](url)) are removed too
+func stripLinks(text []byte) []byte {
+	for {
+		stripped := inlineLinks.ReplaceAll(text, nil)
+		if len(stripped) == len(text) {
+			return stripped
+		}
+		text = stripped
+	}
+}
+
+// Parses "<context>=<severity>" rules
+func parseContextRules(rules []string) (map[string]string, error) {
+	parsed := map[string]string{}
+	for _, rule := range rules {
+		context, severity, _ := strings.Cut(rule, "=")
+		switch context {
+		case contextProse, contextBadge, contextTable, contextFootnote, contextHeading:
+		default:
+			return nil, errors.New("[ERR] Unknown link context " + context)
+		}
+		switch severity {
+		case severityError, severityWarning, severityIgnore:
+		default:
+			return nil, errors.New("[ERR] Unknown severity " + severity + " of " + context + " links")
+		}
+		parsed[context] = severity
+	}
+	return parsed, nil
+}
+
+// Returns severity of failed links found in context (error by default)
+func (opts *Options) contextSeverity(context string) string {
+	if severity, ok := opts.ContextRules[context]; ok {
+		return severity
+	}
+	return severityError
+}
+
+// Returns true if link's failure is reported as a warning only
+func (l MdLink) IsWarning() bool {
+	return l.Severity != nil && *l.Severity == severityWarning
+}
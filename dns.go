@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDnsTimeout = 5 * time.Second
+	dnsCacheTtl       = 5 * time.Minute // long-running servers resolve hosts again after it
+)
+
+// Resolved host, failed lookups are cached too
+type dnsEntry struct {
+	done     chan struct{} // closed when the lookup is completed
+	ips      []net.IP
+	err      error
+	resolved time.Time
+}
+
+// Host lookups shared by all checks, so repeated domains are resolved once. Every lookup is bounded
+// by timeout, so a slow resolver can't stall scans
+type dnsCache struct {
+	resolver *net.Resolver
+	timeout  time.Duration
+	mu       sync.Mutex
+	entries  map[string]*dnsEntry
+}
+
+// Resolves host with the shared cache of the scan
+func (opts *Options) lookupIP(host string) ([]net.IP, error) {
+	if opts == nil {
+		return net.LookupIP(host)
+	}
+	return opts.dns.lookupIP(host)
+}
+
+func newDnsCache(timeout time.Duration) *dnsCache {
+	return &dnsCache{resolver: net.DefaultResolver, timeout: timeout, entries: map[string]*dnsEntry{}}
+}
+
+// Returns host's addresses. Lookups of a host, which is being resolved, wait for its result.
+// Nil cache resolves host every time
+func (d *dnsCache) lookupIP(host string) ([]net.IP, error) {
+	if d == nil {
+		return net.LookupIP(host)
+	}
+	d.mu.Lock()
+	if entry, found := d.entries[host]; found {
+		d.mu.Unlock()
+		<-entry.done
+		if time.Since(entry.resolved) < dnsCacheTtl {
+			return entry.ips, entry.err
+		}
+		d.mu.Lock()
+		// Another lookup might have replaced the expired entry meanwhile
+		if d.entries[host] != entry {
+			d.mu.Unlock()
+			return d.lookupIP(host)
+		}
+	}
+	entry := &dnsEntry{done: make(chan struct{})}
+	d.entries[host] = entry
+	d.mu.Unlock()
+	ctx := context.Background()
+	if d.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+	}
+	entry.ips, entry.err = d.resolver.LookupIP(ctx, "ip", host)
+	entry.resolved = time.Now()
+	close(entry.done)
+	return entry.ips, entry.err
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Hosts the tool needs: API (repository lists) and codeload (archives)
+var doctorHosts = []string{"api.github.com", "codeload.github.com", "github.com"}
+
+// Result of a single environment check
+type doctorResult struct {
+	Name   string
+	Detail string
+	Fix    string // what to do, if check failed
+	Failed bool
+	Warn   bool
+}
+
+func (r doctorResult) String() string {
+	state := "[INF]"
+	switch {
+	case r.Failed:
+		state = "[ERR]"
+	case r.Warn:
+		state = "[WRN]"
+	}
+	s := state + " " + r.Name + ": " + r.Detail
+	if r.Fix != "" && (r.Failed || r.Warn) {
+		s += "\n\t" + r.Fix
+	}
+	return s
+}
+
+// Checks that work directory (where archives are downloaded) is writable
+func doctorWorkDir(dir string) doctorResult {
+	r := doctorResult{Name: "work directory", Detail: dir + " is writable"}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		r.Detail, r.Failed, r.Fix = err.Error(), true, "run gmuv from a writable directory"
+		return r
+	}
+	f, err := os.CreateTemp(dir, "doctor-*")
+	if err != nil {
+		r.Detail, r.Failed, r.Fix = err.Error(), true, "run gmuv from a writable directory"
+		return r
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return r
+}
+
+// Reports proxy, which is used for GitHub requests, and checks that it's reachable
+func doctorProxy() doctorResult {
+	r := doctorResult{Name: "proxy", Detail: "not used (HTTPS_PROXY isn't set)"}
+	req, _ := http.NewRequest(http.MethodGet, "https://api.github.com", nil)
+	proxy, err := http.ProxyFromEnvironment(req)
+	switch {
+	case err != nil:
+		r.Detail, r.Failed, r.Fix = "invalid proxy URL: "+err.Error(), true, "fix HTTPS_PROXY (e.g. http://proxy:3128)"
+	case proxy != nil:
+		r.Detail = proxy.Redacted()
+		host := proxy.Host
+		if proxy.Port() == "" {
+			host = net.JoinHostPort(proxy.Hostname(), "80")
+		}
+		conn, err := net.DialTimeout("tcp", host, 10*time.Second)
+		if err != nil {
+			r.Detail, r.Failed, r.Fix = proxy.Redacted()+" isn't reachable: "+err.Error(), true, "check HTTPS_PROXY or add GitHub hosts to NO_PROXY"
+			return r
+		}
+		conn.Close()
+		r.Detail += " is reachable"
+	}
+	return r
+}
+
+// Resolves GitHub hosts
+func doctorDns(host string) doctorResult {
+	r := doctorResult{Name: "DNS " + host}
+	start := time.Now()
+	addrs, err := net.LookupHost(host)
+	if err != nil {
+		r.Detail, r.Failed, r.Fix = err.Error(), true, "check /etc/resolv.conf or DNS server of your network"
+		return r
+	}
+	r.Detail = strings.Join(addrs, ", ") + " (" + time.Since(start).Round(time.Millisecond).String() + ")"
+	if time.Since(start) > 2*time.Second {
+		r.Warn, r.Fix = true, "DNS is slow, link checks might time out"
+	}
+	return r
+}
+
+// Sends request to GitHub host, any HTTP response means host is reachable
+func doctorConnect(client *http.Client, link string) doctorResult {
+	r := doctorResult{Name: "connectivity " + link}
+	start := time.Now()
+	resp, err := client.Head(link)
+	if err != nil {
+		r.Detail, r.Failed, r.Fix = err.Error(), true, "check firewall, proxy and TLS inspection settings"
+		return r
+	}
+	resp.Body.Close()
+	r.Detail = resp.Status + " (" + time.Since(start).Round(time.Millisecond).String() + ")"
+	return r
+}
+
+// Validates token and reports its scopes and API rate limit
+func doctorToken(client *http.Client, token string) doctorResult {
+	r := doctorResult{Name: "token"}
+	link := "https://api.github.com/rate_limit"
+	if token != "" {
+		link = "https://api.github.com/user"
+	}
+	req, _ := http.NewRequest(http.MethodGet, link, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		r.Detail, r.Failed = err.Error(), true
+		return r
+	}
+	defer resp.Body.Close()
+	limit := resp.Header.Get("X-RateLimit-Remaining") + "/" + resp.Header.Get("X-RateLimit-Limit") + " API requests left"
+	switch {
+	case token == "":
+		r.Detail, r.Warn, r.Fix = "not set, "+limit, true, "set GITHUB_TOKEN to raise API rate limit to 5000 requests/hour"
+	case resp.StatusCode == http.StatusUnauthorized:
+		r.Detail, r.Failed, r.Fix = "invalid or expired", true, "create a new token (no scopes are needed for public repositories)"
+	case resp.StatusCode != http.StatusOK:
+		r.Detail, r.Failed = resp.Status, true
+	default:
+		var user struct {
+			Login string `json:"login"`
+		}
+		json.NewDecoder(resp.Body).Decode(&user)
+		scopes := resp.Header.Get("X-OAuth-Scopes")
+		if scopes == "" {
+			scopes = "none"
+		}
+		r.Detail = "valid (" + user.Login + "), scopes: " + scopes + ", " + limit
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		reset, _ := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		r.Failed, r.Fix = true, "API rate limit is exceeded until "+time.Unix(reset, 0).Format(time.Kitchen)+", repository lists will be empty"
+	}
+	return r
+}
+
+// Checks that account exists and has public repositories
+func doctorAccount(client *http.Client, account, token string) doctorResult {
+	r := doctorResult{Name: "account " + account}
+	req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/users/"+url.PathEscape(account), nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		r.Detail, r.Failed = err.Error(), true
+		return r
+	}
+	defer resp.Body.Close()
+	var user struct {
+		Type        string `json:"type"`
+		PublicRepos int    `json:"public_repos"`
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		r.Detail, r.Failed, r.Fix = "doesn't exist", true, "check account name"
+	case resp.StatusCode != http.StatusOK:
+		r.Detail, r.Failed = resp.Status, true
+	case json.NewDecoder(resp.Body).Decode(&user) != nil:
+		r.Detail, r.Failed = "couldn't parse API response", true
+	default:
+		r.Detail = fmt.Sprintf("%s with %d public repositories", strings.ToLower(user.Type), user.PublicRepos)
+		if user.PublicRepos == 0 {
+			r.Warn, r.Fix = true, "only public repositories are checked"
+		}
+	}
+	return r
+}
+
+// Runs all environment checks and prints diagnostics. Returns error if any check failed
+func runDoctor(account, token, workDir string) error {
+	client := &http.Client{Timeout: 15 * time.Second}
+	results := []doctorResult{doctorWorkDir(workDir), doctorProxy()}
+	for _, host := range doctorHosts {
+		results = append(results, doctorDns(host))
+	}
+	results = append(results,
+		doctorConnect(client, "https://api.github.com"),
+		doctorConnect(client, "https://codeload.github.com"),
+		doctorToken(client, token),
+	)
+	if account != "" {
+		results = append(results, doctorAccount(client, account, token))
+	}
+	failed := 0
+	for _, r := range results {
+		fmt.Println(r)
+		if r.Failed {
+			failed++
+		}
+	}
+	if failed != 0 {
+		return errors.New("[ERR] " + strconv.Itoa(failed) + " check(s) failed")
+	}
+	fmt.Println("[INF] No problems found")
+	return nil
+}
+
+// Returns "doctor" command, which diagnoses environment (network, proxy, token, work directory)
+func doctorCommand(account *string, opts *Options) *cli.Command {
+	var token string
+	return &cli.Command{
+		Name:      "doctor",
+		Usage:     "Diagnose connectivity to GitHub, proxy, DNS, token and work directory",
+		ArgsUsage: "[account]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "token",
+				Usage:       "GitHub token to validate",
+				EnvVars:     []string{"GITHUB_TOKEN"},
+				Destination: &token,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			name := *account
+			if c.Args().First() != "" {
+				name = c.Args().First()
+			}
+			if token == "" {
+				token = opts.Token
+			}
+			if err := runDoctor(name, token, opts.WorkDir); err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+			return nil
+		},
+	}
+}
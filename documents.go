@@ -0,0 +1,68 @@
+package main
+
+import "strings"
+
+// Returns links of a document as "[text](target)" with their offsets and references without definitions
+type documentExtractor func(content []byte, opts *Options) (links, undefined []referenceLink)
+
+// Link extractors of supported documents by file extension
+var documentExtractors = map[string]documentExtractor{
+	"md":       markdownDocumentLinks,
+	"adoc":     plainExtractor(asciidocLinks),
+	"asciidoc": plainExtractor(asciidocLinks),
+	"rst":      plainExtractor(rstLinks),
+	"html":     plainExtractor(htmlDocumentLinks),
+	"htm":      plainExtractor(htmlDocumentLinks),
+	"ipynb":    notebookLinks,
+}
+
+// Adapts extractor of a format, which has no options and no reference definitions
+func plainExtractor(extract func(content []byte) []referenceLink) documentExtractor {
+	return func(content []byte, _ *Options) ([]referenceLink, []referenceLink) {
+		return extract(content), nil
+	}
+}
+
+// Returns extractor of a document, nil if file isn't checked. Extensions (--extensions) replace supported
+// ones: files without a dedicated extractor (markdown, mdx, txt) are extracted as markdown. Otherwise
+// HTML files are documents only if they are checked (--check-html)
+func (opts *Options) documentExtractor(name string) documentExtractor {
+	ext := strings.ToLower(getFileExtension(name))
+	extract, supported := documentExtractors[ext]
+	if opts != nil && len(opts.Extensions) > 0 {
+		switch {
+		case !containsString(opts.Extensions, ext):
+			return nil
+		case !supported:
+			return markdownDocumentLinks
+		}
+		return extract
+	}
+	if (ext == "html" || ext == "htm") && (opts == nil || !opts.CheckHtml) {
+		return nil
+	}
+	return extract
+}
+
+// Returns true if file is a checked document, which is extracted as markdown
+func (opts *Options) isMarkdown(name string) bool {
+	ext := strings.ToLower(getFileExtension(name))
+	_, supported := documentExtractors[ext]
+	return opts.isDocument(name) && (ext == "md" || !supported)
+}
+
+// Returns true if file is a checked document
+func (opts *Options) isDocument(name string) bool {
+	return opts.documentExtractor(name) != nil
+}
+
+// Returns file extensions (lower case, without leading dot)
+func parseExtensions(extensions []string) []string {
+	var parsed []string
+	for _, ext := range extensions {
+		if ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), ".")); ext != "" {
+			parsed = append(parsed, ext)
+		}
+	}
+	return parsed
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxDownloadAttempts   = 4
+	defaultSecondaryPause = time.Minute // GitHub asks to wait at least a minute if no Retry-After is sent
+	maxSecondaryPause     = 15 * time.Minute
+)
+
+// Spaces archive download starts and pauses all downloads while GitHub's secondary rate limit
+// cools down. Whole-account scans start every repository's download at once, which GitHub treats as abuse
+type downloadGate struct {
+	stagger time.Duration // minimal interval between download starts, random jitter up to it is added
+
+	mu     sync.Mutex
+	next   time.Time // earliest start of the next download
+	paused time.Time // no downloads start before that
+}
+
+func newDownloadGate(stagger time.Duration) *downloadGate {
+	return &downloadGate{stagger: stagger}
+}
+
+// Waits for download's turn. Nil gate doesn't wait
+func (g *downloadGate) wait() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	now := time.Now()
+	start := g.next
+	if start.Before(now) {
+		start = now
+	}
+	if start.Before(g.paused) {
+		start = g.paused
+	}
+	var jitter time.Duration
+	if g.stagger > 0 {
+		jitter = time.Duration(rand.Int63n(int64(g.stagger)))
+	}
+	g.next = start.Add(g.stagger + jitter)
+	g.mu.Unlock()
+	time.Sleep(time.Until(start))
+}
+
+// Pauses all downloads for d (pause isn't shortened by later calls)
+func (g *downloadGate) pause(d time.Duration) {
+	if g == nil {
+		time.Sleep(d)
+		return
+	}
+	g.mu.Lock()
+	if until := time.Now().Add(d); until.After(g.paused) {
+		g.paused = until
+	}
+	g.mu.Unlock()
+}
+
+// Returns how long to wait, if response is GitHub's rate limit (primary or secondary) rejection.
+// Attempt (starting at 1) doubles the default pause, as GitHub asks to back off exponentially
+func rateLimitPause(resp *http.Response, body []byte, attempt int) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Until(time.Unix(reset, 0)) + time.Second, true
+		}
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || bytes.Contains(bytes.ToLower(body), []byte("secondary rate limit")) {
+		pause := defaultSecondaryPause << uint(attempt-1)
+		if pause > maxSecondaryPause {
+			pause = maxSecondaryPause
+		}
+		return pause, true
+	}
+	return 0, false
+}
+
+// Downloads repository archive, waiting out rate limits. Returned response has status 200
+func downloadArchive(url string, opts *Options) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		opts.downloads.wait()
+		resp, err := providerGet(url, opts)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+		pause, limited := rateLimitPause(resp, body, attempt)
+		if !limited || attempt == maxDownloadAttempts {
+			return nil, httpStatusError(resp, body)
+		}
+		log.Println("[WRN] Download of " + url + " is rate limited, retrying in " + pause.Round(time.Second).String())
+		opts.downloads.pause(pause)
+	}
+}
+
+// Describes unexpected response
+func httpStatusError(resp *http.Response, body []byte) error {
+	msg := strings.TrimSpace(string(body))
+	if len(msg) > 200 {
+		msg = msg[:200]
+	}
+	if msg == "" {
+		return errors.New(resp.Status)
+	}
+	return errors.New(resp.Status + ": " + msg)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// Networks denied by "private" keyword: loopback, private, link-local (including cloud metadata
+// endpoints like 169.254.169.254), carrier-grade NAT and unspecified addresses
+var privateNetworks = []string{
+	"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12", "192.168.0.0/16",
+	"::/128", "::1/128", "fc00::/7", "fe80::/10",
+}
+
+// Outbound requests policy applied to link checks
+type EgressPolicy struct {
+	Schemes  []string     // allowed URL schemes
+	DenyNets []*net.IPNet // denied destination networks
+	Ports    []int        // allowed destination ports (empty - any)
+}
+
+// Creates egress policy from flag values. Returns nil if no restrictions are set
+func newEgressPolicy(schemes, deny, ports []string) (*EgressPolicy, error) {
+	if len(schemes) == 0 && len(deny) == 0 && len(ports) == 0 {
+		return nil, nil
+	}
+	p := &EgressPolicy{Schemes: []string{"http", "https"}}
+	if len(schemes) > 0 {
+		p.Schemes = nil
+		for _, s := range schemes {
+			p.Schemes = append(p.Schemes, strings.ToLower(strings.TrimSpace(s)))
+		}
+	}
+	for _, d := range deny {
+		cidrs := []string{strings.TrimSpace(d)}
+		if cidrs[0] == "private" {
+			cidrs = privateNetworks
+		}
+		for _, cidr := range cidrs {
+			// Single address is a /32 (or /128) network
+			if !strings.Contains(cidr, "/") {
+				if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+					cidr += "/32"
+				} else {
+					cidr += "/128"
+				}
+			}
+			_, network, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("[ERR] Invalid egress network %s: %w", cidr, err)
+			}
+			p.DenyNets = append(p.DenyNets, network)
+		}
+	}
+	for _, port := range ports {
+		n, err := strconv.Atoi(strings.TrimSpace(port))
+		if err != nil || n <= 0 || n > 65535 {
+			return nil, fmt.Errorf("[ERR] Invalid egress port %s", port)
+		}
+		p.Ports = append(p.Ports, n)
+	}
+	return p, nil
+}
+
+// Checks link's scheme before a request is made
+func (p *EgressPolicy) checkURL(link string) error {
+	if p == nil {
+		return nil
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return err
+	}
+	if !containsString(p.Schemes, strings.ToLower(u.Scheme)) {
+		return errors.New("blocked by egress policy: scheme " + u.Scheme + " is not allowed")
+	}
+	return nil
+}
+
+// Checks actual destination address of every connection (including redirects), so DNS
+// names pointing to denied networks are blocked too
+func (p *EgressPolicy) control(network, address string, _ syscall.RawConn) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if len(p.Ports) > 0 {
+		n, _ := strconv.Atoi(port)
+		allowed := false
+		for _, allowedPort := range p.Ports {
+			allowed = allowed || allowedPort == n
+		}
+		if !allowed {
+			return errors.New("blocked by egress policy: port " + port + " is not allowed")
+		}
+	}
+	ip := net.ParseIP(host)
+	for _, denied := range p.DenyNets {
+		if ip != nil && denied.Contains(ip) {
+			return errors.New("blocked by egress policy: " + host + " is in denied network " + denied.String())
+		}
+	}
+	return nil
+}
+
+// Returns HTTP client for link checks, which respects egress policy. Checks need only status and
+// headers, so response bodies are never downloaded (huge or endless pages don't cost memory or time)
+func newWebClient(opts *Options) *req.Client {
+	client := req.C().DisableAutoReadResponse()
+	if opts != nil && opts.Egress != nil {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: opts.Egress.control}
+		client.SetDial(dialer.DialContext)
+	}
+	if opts != nil {
+		client.SetRedirectPolicy(redirectPolicy(opts.MaxRedirects))
+	}
+	if opts != nil && opts.tls != nil {
+		client.SetTLSClientConfig(opts.tls.Clone())
+	}
+	if opts != nil && opts.recorder != nil {
+		client.GetTransport().WrapRoundTripFunc(func(rt http.RoundTripper) req.HttpRoundTripFunc {
+			return opts.recorder.wrap(rt).RoundTrip
+		})
+	}
+	return client
+}
+
+// Returns HTTP client for API requests (providers, pull requests, archive lookups), which respects
+// egress policy and TLS settings. Proxy is taken from environment and --record/--replay apply as well
+func newApiClient(opts *Options) *http.Client {
+	if opts == nil || (opts.Egress == nil && opts.tls == nil) {
+		// Default client's transport is already wrapped by the recorder
+		return http.DefaultClient
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.Egress != nil {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: opts.Egress.control}
+		transport.DialContext = dialer.DialContext
+	}
+	if opts.tls != nil {
+		transport.TLSClientConfig = opts.tls.Clone()
+	}
+	return &http.Client{Transport: opts.recorder.wrap(transport)}
+}
+
+// Link check and API clients, which are shared by all checks of a run, so connections are reused
+type webClients struct {
+	once         sync.Once
+	plain, http3 *req.Client
+	api          *http.Client
+}
+
+// Returns shared link check client (forced to HTTP/3, if http3 is set). Options without shared clients get a new one
+func (opts *Options) webClient(http3 bool) *req.Client {
+	if opts == nil || opts.clients == nil {
+		if http3 {
+			return newWebClient(opts).EnableForceHTTP3()
+		}
+		return newWebClient(opts)
+	}
+	opts.clients.once.Do(func() {
+		opts.clients.plain = newWebClient(opts)
+		opts.clients.http3 = newWebClient(opts).EnableForceHTTP3()
+		opts.clients.api = newApiClient(opts)
+	})
+	if http3 {
+		return opts.clients.http3
+	}
+	return opts.clients.plain
+}
+
+// Returns shared API client. Options without shared clients get a new one
+func (opts *Options) apiClient() *http.Client {
+	if opts == nil || opts.clients == nil {
+		return newApiClient(opts)
+	}
+	opts.webClient(false)
+	return opts.clients.api
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Converts markdown content to UTF-8 before links are extracted: strips UTF-8 BOM,
+// decodes UTF-16 (with BOM, or detected by zero bytes of ASCII text) and falls back
+// to Latin-1 for invalid UTF-8. Returns detected source encoding
+func decodeContent(content []byte) ([]byte, string) {
+	switch {
+	case bytes.HasPrefix(content, []byte{0xEF, 0xBB, 0xBF}):
+		return content[3:], "utf-8-bom"
+	case bytes.HasPrefix(content, []byte{0xFF, 0xFE}):
+		return decodeUtf16(content[2:], binary.LittleEndian), "utf-16le"
+	case bytes.HasPrefix(content, []byte{0xFE, 0xFF}):
+		return decodeUtf16(content[2:], binary.BigEndian), "utf-16be"
+	case len(content) >= 2 && content[0] != 0 && content[1] == 0:
+		return decodeUtf16(content, binary.LittleEndian), "utf-16le"
+	case len(content) >= 2 && content[0] == 0 && content[1] != 0:
+		return decodeUtf16(content, binary.BigEndian), "utf-16be"
+	case !utf8.Valid(content):
+		return decodeLatin1(content), "latin-1"
+	}
+	return content, "utf-8"
+}
+
+func decodeUtf16(content []byte, order binary.ByteOrder) []byte {
+	units := make([]uint16, 0, len(content)/2)
+	for i := 0; i+1 < len(content); i += 2 {
+		units = append(units, order.Uint16(content[i:]))
+	}
+	// BOM might be duplicated by converting tools
+	return bytes.TrimPrefix([]byte(string(utf16.Decode(units))), []byte("\uFEFF"))
+}
+
+// Every Latin-1 byte is the Unicode code point with the same value
+func decodeLatin1(content []byte) []byte {
+	runes := make([]rune, len(content))
+	for i, b := range content {
+		runes[i] = rune(b)
+	}
+	return []byte(string(runes))
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// How to suppress a single link finding, shared by link codes
+const suppressLink = "To suppress it, list the target in the file's front matter (gmuv: {ignore: [\"<pattern>\"]}) or lower severity of link's context with --context-rules."
+
+// Explanations of codes: what the finding means, how to fix and how to suppress it
+var codeExplanations = map[string]string{
+	codeRateLimited: "The server answered 429 Too Many Requests, so the link's state is unknown rather than broken. " +
+		"Lower --check-workers, set a GitHub token for github.com links or rerun the check later. " + suppressLink,
+	codeDnsFailure: "The link's host name doesn't resolve. The domain usually expired or was mistyped. " +
+		"Fix the host name or replace the link with an archived copy (web.archive.org). " + suppressLink,
+	codeTimeout: "The server accepted no connection or sent no response in time. Transient timeouts disappear on rerun, " +
+		"persistent ones mean the host is gone or blocks the checking network. " + suppressLink,
+	codeTlsError: "The server's certificate is expired, self-signed or issued by an unknown authority. " +
+		"Readers get a browser warning too: link the https page of a host with a valid certificate. " +
+		"Internal hosts with a corporate CA are checked with --ca-cert (or --insecure). " + suppressLink,
+	codeConnectionFailed: "The server refused or reset the connection. The service is down or doesn't listen on the linked port. " +
+		"Update the link if the service moved. " + suppressLink,
+	codeNotFound: "The page responded 404 Not Found. Find its new location (the hint suggests one, when it's known) " +
+		"or replace the link with an archived copy. " + suppressLink,
+	codeGone: "The page responded 410 Gone: it was removed on purpose and won't come back. Remove the link or link a replacement. " + suppressLink,
+	codeAccessDenied: "The page responded 401 or 403. It requires authentication or the server blocks automated requests. " +
+		"Links to private resources don't work for readers either; for bot protection suppress the link. " + suppressLink,
+	codeServerError: "The server responded 5xx. The failure is usually temporary, rerun the check before changing the link. " + suppressLink,
+	codeHttpError:   "The server responded an unexpected HTTP status (e.g. 400 or 418). Open the link in a browser and update it. " + suppressLink,
+	codeMissingFile: "The relative link points to a file or directory, which doesn't exist in the repository. " +
+		"When a file with the same name exists elsewhere, the hint suggests it and --fix rewrites the link. Otherwise fix the path. " + suppressLink,
+	codeMissingAnchor: "The link's #fragment matches no heading or HTML anchor of the target document. Headings are turned into anchors " +
+		"with the slug rules of --slug; update the fragment after a heading was renamed. " + suppressLink,
+	codeEgressDenied: "The link's host is not allowed by the egress policy, so it wasn't requested. " +
+		"Allow the host in the policy, if it should be checked. " + suppressLink,
+	codeMovedPermanently: "The page responded 301/308 Moved Permanently. Update the link to the new location to spare readers a redirect. " +
+		"With --warn-permanent-redirects followed redirects are warnings and --fix updates links to the final URL. " + suppressLink,
+	codeRequestFailed: "The request failed without an HTTP response (malformed URL, unsupported protocol, aborted connection). " +
+		"The reason column holds the underlying error. " + suppressLink,
+	codeTlsProtocol: "Client and server share no TLS version or cipher suite: the server supports only outdated TLS. " +
+		"Readers' browsers reject it too, link another host. " + suppressLink,
+	codeTlsSni: "The server doesn't serve the linked host name or its certificate is issued for another host. " +
+		"Check the host name, the site might have moved to another domain. " + suppressLink,
+	codeTlsHandshake: "The TLS handshake failed, e.g. plain HTTP is served on an https port. Try the link in a browser " +
+		"and switch it to the working protocol. " + suppressLink,
+	codeNotImage: "The image link responded with a non-image Content-Type, often an HTML error or login page, which hosts serve " +
+		"instead of removed images (checked with --check-image-types). Update or remove the image. " + suppressLink,
+	codePlaceholder: "The link is a placeholder: an example domain (example.com, localhost) or a template variable ({{var}}, <name>). " +
+		"Placeholders are skipped by default and reported only with --strict-placeholders; replace them with real links before publishing.",
+	codeUnknownScheme: "The link's URL scheme is neither checked (--schemes) nor skipped (--skip-schemes). " +
+		"Add the scheme to --skip-schemes, when such links are intended, or fix a mistyped scheme.",
+	codePrivateTarget: "A public document links to a private GitHub repository, which responds 404 to its readers. " +
+		"Remove the internal reference or make the target public. " + suppressLink,
+	codeInvalidEmail: "The mailto: address isn't a valid e-mail address (checked with --verify-mailto). Fix the address. " + suppressLink,
+	codeMailDomain: "The mailto: address domain has no mail exchanger (or a null MX) and no address, so mails bounce " +
+		"(checked with --verify-mailto). Update the address. " + suppressLink,
+	codeCaseMismatch: "The relative link differs in case from the target file (readme.md vs README.md). GitHub resolves it, " +
+		"but case-sensitive hosting (static site generators) responds 404. It's a warning; --fix corrects the case.",
+	codeSymlinkLoop: "Following the symlink leads back to a symlink of the chain, so it never reaches a file and isn't checked. " +
+		"Point the symlink to a real file or remove it.",
+	codeDanglingSymlink: "The symlink's target doesn't exist in the repository, so it isn't checked and renders as a broken file. " +
+		"Fix the target path or remove the symlink.",
+	codeOutsideSymlink: "The symlink points outside of the repository (an absolute path or too many ../), which checkouts don't contain. " +
+		"Point it to a repository file or remove it.",
+	codeCaseDuplicate: "Two paths differ only by case (README.md and readme.md). Checkouts on case-insensitive file systems " +
+		"(Windows, macOS) keep only one of them. Rename or remove one of the files.",
+	codeTranscoded: "The file is UTF-16 or Latin-1 encoded, links were found after converting it to UTF-8. " +
+		"GitHub renders such files poorly, so convert the file to UTF-8.",
+	codeUndefinedRef: "The reference-style link ([text][id] or [id][]) has no [id]: <url> definition in the document, " +
+		"so GitHub renders it as plain text. Add the definition or fix the label.",
+	codeOrphaned: "No other document of the repository links to the document, so readers can't find it from the docs. " +
+		"Link it from an index or README, remove it, or declare it an entry point with --entry-point.",
+	codeCrossVersion: "A document of one docs version (docs/v1) links to a document of another version (docs/v2), " +
+		"so readers silently switch versions. Link the document of the same version instead.",
+	codeMissingInLatest: "Older docs versions link to a document, which the latest version doesn't have. " +
+		"Add it to the latest version or update the links, if it was renamed or merged.",
+	codeListFailed: "Repositories of the user or organization couldn't be listed. Check the name, the token's scopes " +
+		"and the API rate limit (gmuv doctor verifies them).",
+	codeDownloadFailed: "The repository archive couldn't be downloaded. Check the repository name, the ref " +
+		"and the token's access to private repositories.",
+	codeArchiveInvalid: "The downloaded archive isn't a valid zip file, usually an error page or a truncated download. Rerun the check.",
+	codeReadFailed:     "A file or directory couldn't be read. Check file permissions of the checked directory.",
+	codeWorkDirFailed:  "The work directory for archives isn't writable. Set a writable directory or free disk space.",
+	codeCloneFailed:    "The git repository couldn't be cloned. Check the URL, credentials and that git is installed.",
+	codeInvalidPath:    "The specified file or directory doesn't exist. Check the --path and --file values.",
+}
+
+// Returns catalogue entry by its code or name (case-insensitive)
+func findErrorCode(id string) (errorCode, bool) {
+	for _, c := range errorCatalogue {
+		if strings.EqualFold(c.Code, id) || strings.EqualFold(c.Name, id) {
+			return c, true
+		}
+	}
+	return errorCode{}, false
+}
+
+// Returns "explain" command, which prints explanation of a code
+func explainCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "explain",
+		Usage:     "Explain a code of reports: what it means, how to fix and how to suppress it",
+		ArgsUsage: "<code or name>",
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return errors.New("[ERR] Specify a code (e.g. GMUV012) or its name (e.g. missing-anchor), 'gmuv codes' lists them")
+			}
+			e, found := findErrorCode(c.Args().First())
+			if !found {
+				return errors.New("[ERR] Unknown code " + c.Args().First() + ", 'gmuv codes' lists them")
+			}
+			fmt.Printf("%s %s\n%s\n\n%s\n", e.Code, e.Name, e.Description, codeExplanations[e.Code])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Optional subsystems (server mode, notifications, self-hosted providers) register themselves in init(),
+// so they can be left out of minimal builds with build tags:
+//
+//	noserver     no "serve" command (HTTP server and scheduler)
+//	nonotify     no notification webhooks
+//	noproviders  GitHub only (no Bitbucket, Gitea/Forgejo and Azure DevOps)
+//	nosql        no SQLite and Postgres storage backends (their database drivers)
+//	minimal      all of the above
+
+// Returns a command, which shares root command's account, repository, report file name and options
+type commandFactory func(account, repo, filename *string, opts *Options) *cli.Command
+
+// Commands of optional subsystems
+var optionalCommands []commandFactory
+
+// Registers command of an optional subsystem
+func registerCommand(f commandFactory) {
+	optionalCommands = append(optionalCommands, f)
+}
+
+// Returns commands of built-in optional subsystems
+func builtinCommands(account, repo, filename *string, opts *Options) []*cli.Command {
+	var commands []*cli.Command
+	for _, f := range optionalCommands {
+		commands = append(commands, f(account, repo, filename, opts))
+	}
+	return commands
+}
+
+// Registers repository hosting provider
+func registerProvider(name string, p provider) {
+	providers[name] = p
+}
+
+// Posts report of a finished check to notification targets. Replaced by notify, when notifications are built in
+var notifyTargets = func(targets []string, reports []*MdReport, elapsed time.Duration) {
+	if len(targets) > 0 {
+		log.Println("[WRN] Notifications aren't built in (nonotify build tag), targets are ignored")
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rewrite of a broken link's target
+type linkFix struct {
+	line, column int
+	from, to     string
+}
+
+// Returns fixes of file's broken links, which have a known new target, and of permanently redirected
+// links, which are updated to the final URL
+func fileFixes(file MdFile) []linkFix {
+	var fixes []linkFix
+	for _, link := range *file.LinkList {
+		var to string
+		switch {
+		case !*link.Succeed && link.Moved != nil:
+			to = *link.Moved
+		case *link.Succeed && hasPermanentRedirect(link.Redirects):
+			to = redirectTarget(*link.Link, link.Redirects[len(link.Redirects)-1].Location)
+		default:
+			continue
+		}
+		fixes = append(fixes, linkFix{line: *link.Line, column: *link.Column, from: linkTarget(*link.Link), to: to})
+	}
+	sortFixes(fixes)
+	return fixes
+}
+
+// Sorts fixes from the end of file, so positions of the remaining ones don't shift when they are applied
+func sortFixes(fixes []linkFix) {
+	sort.Slice(fixes, func(i, j int) bool {
+		if fixes[i].line != fixes[j].line {
+			return fixes[i].line > fixes[j].line
+		}
+		return fixes[i].column > fixes[j].column
+	})
+}
+
+// Applies fixes to content. Target is replaced at (or after) link's position in its line.
+// Returns fixed content and number of applied fixes
+func applyLinkFixes(content []byte, fixes []linkFix) ([]byte, int) {
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	applied := 0
+	for _, fix := range fixes {
+		if fix.line < 1 || fix.line > len(lines) {
+			continue
+		}
+		line := lines[fix.line-1]
+		// Column is counted in runes
+		start := 0
+		for n := 1; n < fix.column && start < len(line); n++ {
+			_, size := utf8.DecodeRune(line[start:])
+			start += size
+		}
+		i := bytes.Index(line[start:], []byte(fix.from))
+		if i < 0 {
+			continue
+		}
+		i += start
+		lines[fix.line-1] = append(append(append([]byte{}, line[:i]...), fix.to...), line[i+len(fix.from):]...)
+		applied++
+	}
+	return bytes.Join(lines, nil), applied
+}
+
+// Returns unified diff of line-aligned contents (fixes never add or remove lines) with 3 lines of context
+func unifiedDiff(name string, before, after []byte) string {
+	a, b := bytes.SplitAfter(before, []byte("\n")), bytes.SplitAfter(after, []byte("\n"))
+	if len(a) != len(b) {
+		return ""
+	}
+	const context = 3
+	var changed []int
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			changed = append(changed, i)
+		}
+	}
+	var out strings.Builder
+	if len(changed) == 0 {
+		return ""
+	}
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", name, name)
+	for h := 0; h < len(changed); {
+		// Changes, which contexts overlap, share a hunk
+		end := h
+		for end+1 < len(changed) && changed[end+1]-changed[end] <= 2*context {
+			end++
+		}
+		first, last := changed[h]-context, changed[end]+context
+		if first < 0 {
+			first = 0
+		}
+		if last >= len(a) {
+			last = len(a) - 1
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", first+1, last-first+1, first+1, last-first+1)
+		for i := first; i <= last; i++ {
+			if bytes.Equal(a[i], b[i]) {
+				out.WriteString(" " + diffLine(a[i]))
+			} else {
+				out.WriteString("-" + diffLine(a[i]) + "+" + diffLine(b[i]))
+			}
+		}
+		h = end + 1
+	}
+	return out.String()
+}
+
+// Returns diff line, which always ends with a line break
+func diffLine(line []byte) string {
+	if bytes.HasSuffix(line, []byte("\n")) {
+		return string(line)
+	}
+	return string(line) + "\n\\ No newline at end of file\n"
+}
+
+// Rewrites links of checked local directories to their suggested targets (moved files, permanent redirects).
+// Preview writes fixed files to a temporary directory and prints their diff, the working tree isn't changed
+func applyFixes(reports []*MdReport, opts *Options) error {
+	if !opts.Fix && !opts.Preview {
+		return nil
+	}
+	var previewDir string
+	var links, files int
+	for _, md := range reports {
+		if md == nil || md.LocalPath == nil || md.MdFileList == nil {
+			continue
+		}
+		for _, file := range *md.MdFileList {
+			fixes := fileFixes(file)
+			if len(fixes) == 0 {
+				continue
+			}
+			name := filepath.Join(*md.LocalPath, filepath.FromSlash(*file.Path))
+			content, err := os.ReadFile(name)
+			if err != nil {
+				return fmt.Errorf("[ERR] Couldn't read %s: %w", name, err)
+			}
+			fixed, applied := applyLinkFixes(content, fixes)
+			if applied == 0 {
+				continue
+			}
+			links += applied
+			files++
+			if !opts.Preview {
+				if err := os.WriteFile(name, fixed, 0644); err != nil {
+					return fmt.Errorf("[ERR] Couldn't write %s: %w", name, err)
+				}
+				continue
+			}
+			if previewDir == "" {
+				if previewDir, err = os.MkdirTemp("", "gmuv-preview-"); err != nil {
+					return err
+				}
+			}
+			preview := filepath.Join(previewDir, filepath.FromSlash(*file.Path))
+			if err := os.MkdirAll(filepath.Dir(preview), 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(preview, fixed, 0644); err != nil {
+				return err
+			}
+			fmt.Fprint(os.Stdout, unifiedDiff(*file.Path, content, fixed))
+		}
+	}
+	switch {
+	case opts.Preview && files > 0:
+		log.Printf("[INF] Preview of %d fixed links in %d files is written to %s, the working tree wasn't changed\n", links, files, previewDir)
+	case opts.Preview || files == 0:
+		log.Println("[INF] No links can be fixed automatically")
+	default:
+		log.Printf("[INF] Fixed %d links in %d files\n", links, files)
+	}
+	return nil
+}
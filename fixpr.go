@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Sends GitHub REST API request with JSON payload and decodes JSON response to result (if not nil)
+func githubRequest(method, apiUrl, token string, payload, result interface{}, opts *Options) error {
+	var content []byte
+	if payload != nil {
+		content, _ = json.Marshal(payload)
+	}
+	request, err := http.NewRequest(method, apiUrl, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := opts.apiClient().Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		return errors.New(method + " " + apiUrl + " responded " + resp.Status + ": " + e.Message)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// Wayback Machine API, which returns the closest archived snapshot of a URL
+const waybackApi = "https://archive.org/wayback/available?url="
+
+// Returns URL of the latest archived snapshot of a page, or empty string if the Wayback Machine has none
+func waybackSnapshot(link string, opts *Options) string {
+	resp, err := opts.apiClient().Get(waybackApi + url.QueryEscape(link))
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	var availability struct {
+		ArchivedSnapshots struct {
+			Closest struct {
+				Available bool   `json:"available"`
+				Url       string `json:"url"`
+				Status    string `json:"status"`
+			} `json:"closest"`
+		} `json:"archived_snapshots"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&availability) != nil {
+		return ""
+	}
+	closest := availability.ArchivedSnapshots.Closest
+	if !closest.Available || closest.Status != "200" {
+		return ""
+	}
+	return strings.Replace(closest.Url, "http://", "https://", 1)
+}
+
+// Returns fixes, which replace file's dead external links (404, 410 or unresolvable domain) with their
+// archived snapshots. Snapshots are memoized, so every URL is looked up once per repository
+func archivedFixes(file MdFile, snapshots map[string]string, opts *Options) []linkFix {
+	var fixes []linkFix
+	for _, link := range *file.LinkList {
+		if *link.Succeed || link.Moved != nil || link.Code == nil {
+			continue
+		}
+		if code := *link.Code; code != codeNotFound && code != codeGone && code != codeDnsFailure {
+			continue
+		}
+		target := linkTarget(*link.Link)
+		if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+			continue
+		}
+		snapshot, ok := snapshots[target]
+		if !ok {
+			snapshot = waybackSnapshot(target, opts)
+			snapshots[target] = snapshot
+		}
+		if snapshot != "" {
+			fixes = append(fixes, linkFix{line: *link.Line, column: *link.Column, from: target, to: snapshot})
+		}
+	}
+	return fixes
+}
+
+// Opens a pull request per checked GitHub repository, which rewrites links to their suggested
+// targets (moved files, permanent redirects, archived copies of dead pages). The description lists every fix and the number of links
+// left to fix manually
+func openFixPullRequests(reports []*MdReport, opts *Options) error {
+	if !opts.FixPr {
+		return nil
+	}
+	token := opts.githubToken()
+	if token == "" {
+		return errors.New("[ERR] Pull requests with fixes need a GitHub token")
+	}
+	for _, md := range reports {
+		if md == nil || md.LocalPath != nil || md.MdFileList == nil || md.Repository.HTMLURL == nil || !isGithubUrl(*md.Repository.HTMLURL) {
+			continue
+		}
+		prUrl, err := openFixPullRequest(md, token, opts)
+		switch {
+		case err != nil:
+			log.Println("[WRN] Couldn't open pull request with fixes for " + *md.Repository.Name + ": " + err.Error())
+		case prUrl != "":
+			log.Println("[INF] Pull request with fixes for " + *md.Repository.Name + ": " + prUrl)
+		}
+	}
+	return nil
+}
+
+// Commits fixes of repository's files to a new branch and opens a pull request. Returns its URL,
+// or empty string if there is nothing to fix. The branch is created only when fixed contents are
+// known, and it's deleted if committing them or opening the pull request fails
+func openFixPullRequest(md *MdReport, token string, opts *Options) (prUrl string, err error) {
+	broken := 0
+	for _, file := range *md.MdFileList {
+		for _, link := range *file.LinkList {
+			if !*link.Succeed && !link.IsWarning() && link.Moved == nil {
+				broken++
+			}
+		}
+	}
+	api := "https://api.github.com/repos/" + strings.TrimPrefix(*md.Repository.HTMLURL, "https://github.com/")
+	base := md.Repository.ref()
+	// Fixed file's content and the blob it replaces
+	type fixedFile struct {
+		path, url, sha string
+		content        []byte
+		applied        int
+	}
+	var files []fixedFile
+	var lines []string
+	snapshots := map[string]string{}
+	for _, file := range *md.MdFileList {
+		archived := archivedFixes(file, snapshots, opts)
+		broken -= len(archived)
+		fixes := append(fileFixes(file), archived...)
+		sortFixes(fixes)
+		if len(fixes) == 0 {
+			continue
+		}
+		var content struct {
+			Sha     string `json:"sha"`
+			Content string `json:"content"`
+		}
+		contentUrl := api + "/contents/" + (&url.URL{Path: *file.Path}).EscapedPath()
+		if err := githubRequest(http.MethodGet, contentUrl+"?ref="+url.QueryEscape(base), token, nil, &content, opts); err != nil {
+			return "", err
+		}
+		original, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(content.Content, "\n", ""))
+		if err != nil {
+			return "", err
+		}
+		updated, applied := applyLinkFixes(original, fixes)
+		if applied == 0 {
+			continue
+		}
+		files = append(files, fixedFile{path: *file.Path, url: contentUrl, sha: content.Sha, content: updated, applied: applied})
+		for i := len(fixes) - 1; i >= 0; i-- {
+			lines = append(lines, fmt.Sprintf("- `%s` → `%s` in [%s:%d](%s#L%d)", fixes[i].from, fixes[i].to, *file.Path, fixes[i].line, md.FilesUrl()+*file.Path, fixes[i].line))
+		}
+	}
+	if len(files) == 0 {
+		return "", nil
+	}
+	var ref struct {
+		Object struct {
+			Sha string `json:"sha"`
+		} `json:"object"`
+	}
+	if err := githubRequest(http.MethodGet, api+"/git/ref/heads/"+base, token, nil, &ref, opts); err != nil {
+		return "", err
+	}
+	branch := "gmuv/fix-links-" + time.Now().UTC().Format("20060102-150405")
+	if err := githubRequest(http.MethodPost, api+"/git/refs", token, map[string]string{"ref": "refs/heads/" + branch, "sha": ref.Object.Sha}, nil, opts); err != nil {
+		return "", err
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if deleteErr := githubRequest(http.MethodDelete, api+"/git/refs/heads/"+branch, token, nil, nil, opts); deleteErr != nil {
+			log.Println("[WRN] Couldn't delete branch " + branch + ": " + deleteErr.Error())
+		}
+	}()
+	for _, file := range files {
+		commit := map[string]string{
+			"message": fmt.Sprintf("Fix %d links in %s", file.applied, file.path),
+			"content": base64.StdEncoding.EncodeToString(file.content),
+			"sha":     file.sha,
+			"branch":  branch,
+		}
+		if err := githubRequest(http.MethodPut, file.url, token, commit, nil, opts); err != nil {
+			return "", err
+		}
+	}
+	body := fmt.Sprintf("gmuv found links with known fixes (files, which were moved, permanent redirects and dead pages, which have archived copies):\n\n%s\n", strings.Join(lines, "\n"))
+	if broken > 0 {
+		body += fmt.Sprintf("\n%d more broken links have no automatic fix, see the full report.\n", broken)
+	}
+	var pr struct {
+		HtmlUrl string `json:"html_url"`
+	}
+	payload := map[string]string{"title": fmt.Sprintf("Fix %d links", len(lines)), "head": branch, "base": base, "body": body}
+	if err := githubRequest(http.MethodPost, api+"/pulls", token, payload, &pr, opts); err != nil {
+		return "", err
+	}
+	return pr.HtmlUrl, nil
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// Per-file settings, which document authors set in markdown front matter:
+//
+//	---
+//	gmuv:
+//	  skip: true
+//	  ignore:
+//	    - https://example.com/*
+//	---
+//
+// or in a flow style: gmuv: {skip: true, ignore: ["*.example.com/*"]}
+type FileConfig struct {
+	Skip   bool     // file isn't checked
+	Ignore []string // link target patterns ('*' matches any characters), which aren't checked
+}
+
+// Parses 'gmuv' key of YAML front matter. Only a small subset of YAML is supported:
+// scalars, flow mappings and lists, block mappings and lists
+func parseFileConfig(content []byte) FileConfig {
+	var config FileConfig
+	lines := frontMatterLines(content)
+	for i := 0; i < len(lines); i++ {
+		value, ok := cutKey(lines[i], "gmuv")
+		if !ok || strings.HasPrefix(lines[i], " ") {
+			continue
+		}
+		if value != "" {
+			for _, item := range splitFlow(strings.Trim(value, "{}")) {
+				k, v, _ := strings.Cut(item, ":")
+				config.set(strings.TrimSpace(k), strings.TrimSpace(v), nil)
+			}
+			break
+		}
+		// Block mapping: indented keys, values might be block lists
+		for i++; i < len(lines) && strings.HasPrefix(lines[i], " "); i++ {
+			k, v, _ := strings.Cut(strings.TrimSpace(lines[i]), ":")
+			var items []string
+			for i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i+1]), "- ") {
+				i++
+				items = append(items, unquote(strings.TrimPrefix(strings.TrimSpace(lines[i]), "- ")))
+			}
+			config.set(strings.TrimSpace(k), strings.TrimSpace(v), items)
+		}
+		break
+	}
+	return config
+}
+
+// Sets setting's value (scalar or flow list) or block list items
+func (c *FileConfig) set(key, value string, items []string) {
+	switch key {
+	case "skip":
+		c.Skip = value == "true" || value == "yes"
+	case "ignore":
+		if strings.HasPrefix(value, "[") {
+			for _, item := range splitFlow(strings.Trim(value, "[]")) {
+				items = append(items, unquote(item))
+			}
+		} else if value != "" {
+			items = append(items, unquote(value))
+		}
+		c.Ignore = append(c.Ignore, items...)
+	}
+}
+
+// Returns true if link's target matches one of ignore patterns
+func (c *FileConfig) ignored(link string) bool {
+	target := linkTarget(link)
+	for _, pattern := range c.Ignore {
+		re := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+		if ok, _ := regexp.MatchString(re, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns front matter lines (between leading '---' and closing '---' or '...')
+func frontMatterLines(content []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "---" {
+		return nil
+	}
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		if line == "---" || line == "..." {
+			return lines
+		}
+		// Comments and blank lines
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return nil
+}
+
+// Returns value of "key: value" line
+func cutKey(line, key string) (string, bool) {
+	k, v, ok := strings.Cut(strings.TrimSpace(line), ":")
+	if !ok || strings.TrimSpace(k) != key {
+		return "", false
+	}
+	return strings.TrimSpace(v), true
+}
+
+// Splits flow collection's content by commas, which aren't inside nested brackets or quotes
+func splitFlow(s string) []string {
+	var items []string
+	var quote rune
+	depth, start := 0, 0
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '[' || r == '{':
+			depth++
+		case r == ']' || r == '}':
+			depth--
+		case r == ',' && depth == 0:
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// Returns link's target: "[text](target)" -> "target"
+func linkTarget(link string) string {
+	// Text may contain nested links ([![badge](img)](url)), so target follows the last "]("
+	if i := strings.LastIndex(link, "]("); i >= 0 {
+		return strings.TrimSuffix(link[i+2:], ")")
+	}
+	return link
+}
+
+// URL-valued front matter field: "key: https://..." (YAML), key = "https://..." (TOML) or a list item
+var frontMatterUrl = regexp.MustCompile(`(?m)^[ \t]*(?:-[ \t]*|([\w.-]+)[ \t]*[:=][ \t]*)["']?(https?://[^\s"']+)`)
+
+// Returns length of front matter: YAML between leading '---' and '---' (or '...') lines, TOML between
+// leading '+++' lines. Zero if content has no front matter
+func frontMatterEnd(content []byte) int {
+	first := lineEnd(content)
+	delimiter := strings.TrimSpace(string(content[:first]))
+	if delimiter != "---" && delimiter != "+++" {
+		return 0
+	}
+	for offset := first + 1; offset < len(content); {
+		n := lineEnd(content[offset:])
+		line := strings.TrimRight(string(content[offset:offset+n]), " \r")
+		offset += n + 1
+		if line == delimiter || delimiter == "---" && line == "..." {
+			if offset > len(content) {
+				return len(content)
+			}
+			return offset
+		}
+	}
+	return 0
+}
+
+// Returns URL-valued fields of front matter as links, named by their keys
+func frontMatterUrls(frontMatter []byte) []referenceLink {
+	var links []referenceLink
+	for _, m := range frontMatterUrl.FindAllSubmatchIndex(frontMatter, -1) {
+		key := "front matter"
+		if m[2] >= 0 {
+			key = string(frontMatter[m[2]:m[3]])
+		}
+		links = append(links, referenceLink{target: "[" + key + "](" + string(frontMatter[m[4]:m[5]]) + ")", offset: m[4], end: m[5]})
+	}
+	return links
+}
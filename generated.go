@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// How many first lines of a file are searched for auto-generation markers
+const generatedHeaderLines = 10
+
+// Markers of generated files, which are comments or headings rather than prose: "<!-- Generated by terraform-docs -->",
+// "<!-- DO NOT EDIT -->", a "Code generated ... DO NOT EDIT." line (Go convention, optionally commented out)
+// and "###### Auto generated by spf13/cobra" heading
+var generatedMarkers = regexp.MustCompile(`(?m)<!--[^>]*(?i:\bgenerated\b|\bdo not edit\b)[^>]*-->|^(?:#+|//)?[ \t]*Code generated .* DO NOT EDIT\.\r?$|^#{1,6}[ \t]+(?i:auto[- ]?generated by)\b`)
+
+// Markers of generated files in front matter: "generated: true" (TOML "generated = true") field or a comment
+var generatedFrontMatter = regexp.MustCompile(`(?mi)^[ \t]*(?:(?:auto_?)?generated[ \t]*[:=][ \t]*true|#.*(?:\bdo not edit\b|\bauto[- ]?generated\b).*)\r?$`)
+
+// Returns true if file was generated: its path matches one of generator path patterns
+// or its front matter or header contains an auto-generation marker. Findings there must be fixed in the generator
+func isGeneratedFile(p string, content []byte, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matchPathPattern(pattern, p) {
+			return true
+		}
+	}
+	if generatedFrontMatter.Match(content[:frontMatterEnd(content)]) {
+		return true
+	}
+	header := content
+	for i, n := 0, 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			if n++; n == generatedHeaderLines {
+				header = content[:i]
+				break
+			}
+		}
+	}
+	return generatedMarkers.Match(bytes.TrimSpace(header))
+}
+
+// Matches '/'-separated path against a pattern: 'dir/' matches everything inside directory,
+// pattern without '/' matches file's name, otherwise whole path is matched (see path.Match)
+func matchPathPattern(pattern, p string) bool {
+	switch {
+	case strings.HasSuffix(pattern, "/"):
+		return strings.HasPrefix(p+"/", strings.TrimPrefix(pattern, "/"))
+	case !strings.Contains(pattern, "/"):
+		ok, _ := path.Match(pattern, path.Base(p))
+		return ok
+	default:
+		ok, _ := path.Match(strings.TrimPrefix(pattern, "/"), p)
+		return ok
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// GitHub API gist
+type Gist struct {
+	Id          string              `json:"id"`
+	HTMLURL     string              `json:"html_url"`
+	Description string              `json:"description"`
+	Public      bool                `json:"public"`
+	Files       map[string]GistFile `json:"files"`
+}
+
+type GistFile struct {
+	Filename string `json:"filename"`
+	RawUrl   string `json:"raw_url"`
+	Size     int64  `json:"size"`
+}
+
+// Returns user's public gists
+func getGists(user string, opts *Options) ([]Gist, error) {
+	var gists []Gist
+	for list := "https://api.github.com/users/" + user + "/gists?per_page=100"; list != ""; {
+		resp, err := githubGet(list, opts.githubToken())
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("[ERR] Couldn't list %s gists: %s", user, resp.Status)
+		}
+		var page []Gist
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		gists = append(gists, page...)
+		list = nextPageUrl(resp.Header.Get("Link"))
+	}
+	return gists, nil
+}
+
+// Returns gist's documents (markdown, AsciiDoc, reStructuredText, notebooks), which are downloaded when they're read
+func (g *Gist) sourceFiles(opts *Options) []SourceFile {
+	var files []SourceFile
+	for _, f := range g.Files {
+		if !opts.isDocument(f.Filename) {
+			continue
+		}
+		files = append(files, rawSourceFile(f.Filename, f.RawUrl, f.Size, opts))
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
+
+// Checks markdown files of user's public gists, every gist is reported separately
+func runGistCheck(user string, opts *Options, outputs []*ReportOutput) ([]*MdReport, error) {
+	var mdList MdReportList
+	var wg sync.WaitGroup
+	gists, err := getGists(user, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range gists {
+		g := &gists[i]
+		files := g.sourceFiles(opts)
+		if !g.Public || len(files) == 0 {
+			continue
+		}
+		name := g.Id
+		webUrl := g.HTMLURL
+		allLinksDefVal := true
+		md := &MdReport{
+			Repository: &Repository{Name: &name, HTMLURL: &webUrl, WebUrl: &webUrl, TreeUrl: &webUrl},
+			Files:      files,
+			AllLinksOK: &allLinksDefVal,
+			Options:    opts,
+		}
+		mdList.Reports = append(mdList.Reports, md)
+	}
+	for _, md := range mdList.Reports {
+		wg.Add(1)
+		go func(m *MdReport) {
+			defer wg.Done()
+			checkMdFiles(m, &mdList.Mu, outputs)
+		}(md)
+	}
+	wg.Wait()
+	return mdList.Reports, nil
+}
@@ -0,0 +1,129 @@
+//go:build !noproviders && !minimal
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	registerProvider("gitea", provider{getGiteaRepos, setGiteaUrls, true, giteaNamedRef})
+	registerProvider("forgejo", provider{getGiteaRepos, setGiteaUrls, true, giteaNamedRef})
+}
+
+// Gitea/Forgejo API repository (shares most fields with GitHub)
+type GiteaRepository struct {
+	Repository
+	Private *bool `json:"private,omitempty"`
+	Empty   *bool `json:"empty,omitempty"`
+}
+
+func (g *GiteaRepository) repository() *Repository {
+	r := g.Repository
+	return &r
+}
+
+// Sets Gitea's archive and web URLs: files and directories are opened as /src/branch/<branch>/<path>
+// (or /src/commit/<sha>/<path>, if commit is checked)
+func setGiteaUrls(r *Repository) {
+	archiveUrl := *r.HTMLURL + "/archive/" + r.ref() + ".zip"
+	webUrl := *r.HTMLURL + "/src/branch/" + r.ref()
+	if r.RefCommit {
+		webUrl = *r.HTMLURL + "/src/commit/" + r.ref()
+	}
+	r.ArchiveUrl, r.WebUrl, r.TreeUrl = &archiveUrl, &webUrl, &webUrl
+}
+
+// Returns true if Gitea repository has a branch or tag named ref (repository's URL is its API URL)
+func giteaNamedRef(r *Repository, ref string, opts *Options) bool {
+	if r.URL == nil {
+		return false
+	}
+	for _, kind := range []string{"/branches/", "/tags/"} {
+		resp, err := providerGet(*r.URL+kind+url.PathEscape(ref), opts)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if repository is public and not empty
+func (g *GiteaRepository) active() bool {
+	isTrue := func(b *bool) bool { return b != nil && *b }
+	return !isTrue(g.Private) && !isTrue(g.Empty) && g.HTMLURL != nil && g.DefaultBranch != nil
+}
+
+// Returns public/not-empty repositories of Gitea/Forgejo user or organization
+func getGiteaRepos(owner, repo string, opts *Options) ([]*Repository, error) {
+	var outRepos []*Repository
+	if opts.ProviderUrl == "" {
+		return nil, errors.New("[ERR] Gitea/Forgejo server URL (--provider-url) is not specified")
+	}
+	api := strings.TrimSuffix(opts.ProviderUrl, "/") + "/api/v1"
+
+	if repo != "" {
+		resp, err := providerGet(api+"/repos/"+owner+"/"+repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, nil
+		}
+		var g GiteaRepository
+		if err := json.NewDecoder(resp.Body).Decode(&g); err != nil {
+			return nil, err
+		}
+		if g.HTMLURL != nil && g.DefaultBranch != nil {
+			outRepos = append(outRepos, g.repository())
+		}
+		return outRepos, nil
+	}
+
+	// Owner might be a user or an organization
+	list := api + "/users/" + owner + "/repos"
+	for page := 1; ; page++ {
+		resp, err := providerGet(list+"?limit=50&page="+strconv.Itoa(page), opts)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusNotFound && page == 1 && strings.Contains(list, "/users/") {
+			resp.Body.Close()
+			list = api + "/orgs/" + owner + "/repos"
+			page = 0
+			continue
+		}
+		var repos []GiteaRepository
+		err = json.NewDecoder(resp.Body).Decode(&repos)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("[ERR] Couldn't list %s repositories: %s", owner, resp.Status)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(repos) == 0 {
+			break
+		}
+		for i := range repos {
+			if repos[i].active() && opts.includes(&repos[i].Repository) {
+				outRepos = append(outRepos, repos[i].repository())
+			}
+		}
+		if opts.enoughRepos(outRepos) {
+			break
+		}
+	}
+	return outRepos, nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Installation token is refreshed this long before it expires
+const appTokenRefreshMargin = 5 * time.Minute
+
+// GitHub App, which authenticates as its installation. Installation tokens live for an hour
+// and are refreshed automatically
+type githubApp struct {
+	id           string
+	key          *rsa.PrivateKey
+	installation string // looked up by account, if it isn't specified
+	account      string
+	mu           sync.Mutex
+	token        string
+	expires      time.Time
+}
+
+// Loads GitHub App's private key (PKCS#1 or PKCS#8 PEM)
+func loadGithubApp(id, keyFile, installation, account string) (*githubApp, error) {
+	content, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("[ERR] Couldn't load GitHub App key %s: %w", keyFile, err)
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("[ERR] GitHub App key " + keyFile + " is not a PEM file")
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, err8 := x509.ParsePKCS8PrivateKey(block.Bytes)
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if err8 != nil || !ok {
+			return nil, errors.New("[ERR] GitHub App key " + keyFile + " is not an RSA private key")
+		}
+		key = rsaKey
+	}
+	app := &githubApp{id: id, key: key, installation: installation, account: account}
+	if _, err := app.accessToken(); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// Returns App's JSON Web Token (RS256), which is valid for 9 minutes
+func (a *githubApp) jwt() (string, error) {
+	now := time.Now()
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	// Issued a minute earlier to allow clock drift
+	claims, _ := json.Marshal(map[string]interface{}{"iat": now.Add(-time.Minute).Unix(), "exp": now.Add(9 * time.Minute).Unix(), "iss": a.id})
+	unsigned := header + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+// Returns installation ID of the account (user or organization)
+func (a *githubApp) lookupInstallation(jwt string) (string, error) {
+	if a.account == "" {
+		return "", errors.New("[ERR] GitHub App installation (--app-installation) or account (--username) must be specified")
+	}
+	for _, list := range []string{"/users/", "/orgs/"} {
+		var installation struct {
+			Id int64 `json:"id"`
+		}
+		resp, err := githubGet("https://api.github.com"+list+a.account+"/installation", jwt)
+		if err != nil {
+			return "", err
+		}
+		err = json.NewDecoder(resp.Body).Decode(&installation)
+		resp.Body.Close()
+		if err == nil && resp.StatusCode == http.StatusOK && installation.Id != 0 {
+			return strconv.FormatInt(installation.Id, 10), nil
+		}
+	}
+	return "", errors.New("[ERR] GitHub App " + a.id + " isn't installed on " + a.account + " account")
+}
+
+// Returns installation token, a new one is created if current token expires soon.
+// Current token is used until it expires, if it couldn't be refreshed
+func (a *githubApp) accessToken() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && time.Until(a.expires) > appTokenRefreshMargin {
+		return a.token, nil
+	}
+	if err := a.refresh(); err != nil {
+		if a.token != "" && time.Now().Before(a.expires) {
+			log.Println(err)
+			return a.token, nil
+		}
+		return "", err
+	}
+	return a.token, nil
+}
+
+// Creates a new installation token
+func (a *githubApp) refresh() error {
+	jwt, err := a.jwt()
+	if err != nil {
+		return err
+	}
+	if a.installation == "" {
+		if a.installation, err = a.lookupInstallation(jwt); err != nil {
+			return err
+		}
+	}
+	request, err := http.NewRequest(http.MethodPost, "https://api.github.com/app/installations/"+a.installation+"/access_tokens", nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+jwt)
+	request.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("[ERR] Couldn't create GitHub App installation token: %w", err)
+	}
+	defer resp.Body.Close()
+	var token struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return errors.New("[ERR] Couldn't create GitHub App installation token: " + resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return err
+	}
+	a.token, a.expires = token.Token, token.ExpiresAt
+	return nil
+}
+
+// Returns token, which authorizes GitHub requests: GitHub App's installation token or personal token
+func (opts *Options) githubToken() string {
+	if opts.app == nil {
+		return opts.Token
+	}
+	token, err := opts.app.accessToken()
+	if err != nil {
+		log.Println(err)
+	}
+	return token
+}
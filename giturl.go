@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Git's messages about a remote repository, which doesn't exist
+var gitRepoNotFound = regexp.MustCompile(`(?i)repository not found|repository '[^']*' not found`)
+
+// Clones (shallow) any Git repository and checks its markdown files. Relative links are resolved
+// against web base URL (if set), otherwise they're validated against cloned tree
+func runGitUrlCheck(opts *Options, outputs []*ReportOutput) ([]*MdReport, error) {
+	var mu sync.Mutex
+	repoUrl := strings.TrimSuffix(strings.TrimSuffix(normalizeSlashes(opts.GitUrl), "/"), "/.git")
+	name := strings.TrimSuffix(path.Base(repoUrl), ".git")
+	// Name becomes a directory, which is removed before cloning, so it must never leave the archives directory
+	if name == "" || name == "." || strings.Contains(name, "..") || strings.ContainsAny(name, `/\`) {
+		return nil, errors.New("[ERR] Couldn't get repository name from " + opts.GitUrl)
+	}
+	root, err := filepath.Abs(filepath.Join(opts.WorkDir, name))
+	if err != nil {
+		return nil, err
+	}
+	if err := cloneGitRepo(opts.GitUrl, root, opts.Ref, opts.WorkDir); err != nil {
+		return nil, err
+	}
+	md := newClonedReport(name, opts.GitUrl, root, opts.WebBase, opts)
+	if opts.Ref != "" {
+		md.Repository.Ref = &opts.Ref
+	}
+	checkMdFiles(md, &mu, outputs)
+	return []*MdReport{md}, nil
+}
+
+// Shallow clones Git repository's ref (default branch, if empty) to the directory inside work directory,
+// removing its previous content. Commits can't be cloned by name, so they're fetched into an empty repository.
+// A ref, which looks like a commit SHA, is a commit only if the remote has no branch or tag of that name
+func cloneGitRepo(gitUrl, root, ref, workDir string) error {
+	work, err := filepath.Abs(workDir)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(work, root); err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return errors.New("[ERR] Clone directory " + root + " is outside of " + work)
+	}
+	os.RemoveAll(root)
+	if err := os.MkdirAll(filepath.Dir(root), 0755); err != nil {
+		return err
+	}
+	// URL follows "--", so a value starting with "-" can't inject git options
+	cmds := [][]string{{"clone", "--depth", "1", "--quiet", "--", gitUrl, root}}
+	switch {
+	case commitSha.MatchString(ref) && !gitNamedRef(gitUrl, ref):
+		cmds = [][]string{
+			{"init", "--quiet", root},
+			{"-C", root, "fetch", "--depth", "1", "--quiet", "--", gitUrl, ref},
+			{"-C", root, "checkout", "--quiet", "FETCH_HEAD"},
+		}
+	case ref != "":
+		cmds = [][]string{{"clone", "--depth", "1", "--quiet", "--branch", ref, "--", gitUrl, root}}
+	}
+	for _, args := range cmds {
+		cmd := exec.Command("git", args...)
+		// Missing (wiki) repositories respond 401, git must fail instead of waiting for credentials
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return codedError(codeCloneFailed, "Couldn't clone "+gitUrl+".\n\t"+strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// Returns true if remote Git repository has a branch or tag named ref
+func gitNamedRef(gitUrl, ref string) bool {
+	cmd := exec.Command("git", "ls-remote", "--quiet", "--", gitUrl, "refs/heads/"+ref, "refs/tags/"+ref)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.Output()
+	return err == nil && len(strings.TrimSpace(string(out))) > 0
+}
+
+// Returns report of a cloned repository. Relative links are resolved against web base URL, if it's set
+func newClonedReport(name, gitUrl, root, webBase string, opts *Options) *MdReport {
+	allLinksDefVal := true
+	md := &MdReport{Repository: &Repository{Name: &name, HTMLURL: &gitUrl}, LocalPath: &root, AllLinksOK: &allLinksDefVal, Options: opts}
+	if webBase != "" {
+		base := strings.TrimSuffix(webBase, "/")
+		md.Repository.WebUrl, md.Repository.TreeUrl = &base, &base
+	}
+	return md
+}
+
+// Clones and checks repository's wiki (<repo>.wiki.git). Wiki pages are opened without
+// extension (<repo>/wiki/<page>). Returns nil if git reports that the wiki repository doesn't exist:
+// GitHub reports has_wiki for repositories without wiki pages too. Other clone failures (network,
+// credentials, disk) are execution errors of the wiki's report
+func checkWiki(r *Repository, opts *Options, outputs []*ReportOutput, mu *sync.Mutex) *MdReport {
+	name := *r.Name + ".wiki"
+	root, err := filepath.Abs(filepath.Join(opts.WorkDir, name))
+	md := newClonedReport(name, *r.HTMLURL+"/wiki", root, *r.HTMLURL+"/wiki", opts)
+	if err != nil {
+		md.setError(codeWorkDirFailed, "Couldn't create "+name+" directory.\n\t"+err.Error())
+		writeReport(md, mu, outputs)
+		return md
+	}
+	if err := cloneGitRepo(*r.HTMLURL+".wiki.git", root, "", opts.WorkDir); err != nil {
+		if gitRepoNotFound.MatchString(err.Error()) {
+			log.Println("[INF] " + *r.Name + " has no wiki pages, its wiki isn't checked")
+			return nil
+		}
+		md.setError(codeCloneFailed, "Couldn't clone wiki of "+*r.Name+".\n\t"+strings.TrimPrefix(err.Error(), "[ERR] "+codeCloneFailed+" "))
+		writeReport(md, mu, outputs)
+		return md
+	}
+	checkMdFiles(md, mu, outputs)
+	return md
+}
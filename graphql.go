@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Lists account's repositories with everything needed for filtering, 100 per request
+const graphqlRepositoriesQuery = `query($login: String!, $cursor: String) {
+  repositoryOwner(login: $login) {
+    repositories(first: 100, after: $cursor, ownerAffiliations: OWNER) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        name url isFork isArchived isDisabled isPrivate isEmpty diskUsage hasWikiEnabled
+        owner { login }
+        defaultBranchRef { name }
+        repositoryTopics(first: 20) { nodes { topic { name } } }
+      }
+    }
+  }
+}`
+
+// Page of GraphQL connection
+type graphqlPageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// Runs GraphQL query (or mutation) and decodes its data to result
+func githubGraphql(query string, variables map[string]interface{}, token string, result interface{}) error {
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	request, err := http.NewRequest(http.MethodPost, "https://api.github.com/graphql", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("GraphQL API responded " + resp.Status)
+	}
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return err
+	}
+	if len(response.Errors) != 0 {
+		var messages []string
+		for _, e := range response.Errors {
+			messages = append(messages, e.Message)
+		}
+		return errors.New(strings.Join(messages, "; "))
+	}
+	return json.Unmarshal(response.Data, result)
+}
+
+// GraphQL repository node
+type GraphqlRepository struct {
+	Name           string `json:"name"`
+	Url            string `json:"url"`
+	IsFork         bool   `json:"isFork"`
+	IsArchived     bool   `json:"isArchived"`
+	IsDisabled     bool   `json:"isDisabled"`
+	IsPrivate      bool   `json:"isPrivate"`
+	IsEmpty        bool   `json:"isEmpty"`
+	DiskUsage      int    `json:"diskUsage"`
+	HasWikiEnabled bool   `json:"hasWikiEnabled"`
+	Owner          struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+	DefaultBranchRef *struct {
+		Name string `json:"name"`
+	} `json:"defaultBranchRef"`
+	RepositoryTopics struct {
+		Nodes []struct {
+			Topic struct {
+				Name string `json:"name"`
+			} `json:"topic"`
+		} `json:"nodes"`
+	} `json:"repositoryTopics"`
+}
+
+// Converts GraphQL repository node to a common (REST-like) one
+func (g *GraphqlRepository) repository() *Repository {
+	apiUrl := "https://api.github.com/repos/" + g.Owner.Login + "/" + g.Name
+	r := &Repository{
+		Name:          &g.Name,
+		URL:           &apiUrl,
+		HTMLURL:       &g.Url,
+		Fork:          &g.IsFork,
+		Archived:      &g.IsArchived,
+		Disabled:      &g.IsDisabled,
+		Private:       &g.IsPrivate,
+		DefaultBranch: &g.DefaultBranchRef.Name,
+		Size:          &g.DiskUsage,
+		HasWiki:       &g.HasWikiEnabled,
+	}
+	for _, t := range g.RepositoryTopics.Nodes {
+		r.Topics = append(r.Topics, t.Topic.Name)
+	}
+	return r
+}
+
+// Returns not-empty repositories of a user or an organization using GraphQL API (a single request
+// per 100 repositories instead of REST calls). GraphQL API needs a token
+func getGraphqlRepos(account string, opts *Options) ([]*Repository, error) {
+	var outRepos []*Repository
+	var cursor *string
+	for {
+		var result struct {
+			RepositoryOwner *struct {
+				Repositories struct {
+					PageInfo graphqlPageInfo     `json:"pageInfo"`
+					Nodes    []GraphqlRepository `json:"nodes"`
+				} `json:"repositories"`
+			} `json:"repositoryOwner"`
+		}
+		variables := map[string]interface{}{"login": account, "cursor": cursor}
+		if err := githubGraphql(graphqlRepositoriesQuery, variables, opts.githubToken(), &result); err != nil {
+			return nil, err
+		}
+		owner := result.RepositoryOwner
+		if owner == nil {
+			return nil, errors.New("account " + account + " doesn't exist")
+		}
+		for i := range owner.Repositories.Nodes {
+			g := &owner.Repositories.Nodes[i]
+			if g.IsEmpty || g.DefaultBranchRef == nil {
+				continue
+			}
+			if r := g.repository(); opts.includes(r) {
+				outRepos = append(outRepos, r)
+			}
+		}
+		if !owner.Repositories.PageInfo.HasNextPage || opts.enoughRepos(outRepos) {
+			return outRepos, nil
+		}
+		cursor = &owner.Repositories.PageInfo.EndCursor
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/imroc/req/v3"
+)
+
+// Returns actionable hint for a failed link check, derived from response data and git history
+func remediationHint(md *MdReport, link, repoPath string, status int, r *req.Response, err error) string {
+	var dnsErr *net.DNSError
+	var certErr x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+
+	switch {
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		return "domain " + dnsErr.Name + " is not registered (no DNS record), remove the link or replace it with an archived copy"
+	case errors.As(err, &dnsErr):
+		return "domain " + dnsErr.Name + " couldn't be resolved, check DNS or try again later"
+	case errors.As(err, &hostErr):
+		return "server's TLS certificate is issued for another host (SNI or virtual host misconfiguration), check the link's host name"
+	case errors.As(err, &certErr), errors.As(err, &invalidErr):
+		return "server's TLS certificate is not valid, contact the site owner or link to another source"
+	case tlsFailure(err) == codeTlsProtocol:
+		return "server supports no TLS version or cipher suite in common with the checker (too old or too strict), check it in a browser"
+	case tlsFailure(err) == codeTlsSni:
+		return "server doesn't serve this host name (SNI), the site might have moved to another host or CDN"
+	case tlsFailure(err) == codeTlsHandshake:
+		return "TLS handshake failed, the server (or a proxy in between) might not speak TLS on this port"
+	case err != nil && strings.Contains(err.Error(), "blocked by egress policy"):
+		return "link is not allowed by the egress policy"
+	case err != nil && isTimeout(err):
+		return "server didn't respond in time, it might be down or slow"
+	case err != nil:
+		return ""
+	}
+
+	switch {
+	case status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect:
+		if r != nil && r.Response != nil && r.Header.Get("Location") != "" {
+			return "link was moved permanently, update to " + r.Header.Get("Location")
+		}
+	case status == http.StatusNotFound && repoPath != "":
+		return fileHistoryHint(md, repoPath)
+	case status == http.StatusNotFound && r != nil && r.Response != nil && r.Response.Request != nil && r.Response.Request.URL.String() != link:
+		return "link redirects to " + r.Response.Request.URL.String() + ", which doesn't exist, update or remove the link"
+	case status == http.StatusNotFound && isGithubUrl(link):
+		return "GitHub page doesn't exist (or is private), the repository might be renamed, deleted or made private"
+	case status == http.StatusNotFound:
+		return "page doesn't exist, update or remove the link"
+	case status == http.StatusGone:
+		return "page was removed permanently, remove the link or replace it with an archived copy"
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return "access is denied, the page might require authentication or block automated requests, check it in a browser"
+	case status == http.StatusTooManyRequests:
+		return "server rate limited the check, try again later"
+	case status >= 500:
+		return "server error, it might be temporary, try again later"
+	}
+	return ""
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func isGithubUrl(link string) bool {
+	u, err := url.Parse(link)
+	return err == nil && (u.Host == "github.com" || u.Host == "www.github.com")
+}
+
+// Returns history hint of a missing file. Hints are looked up once per file, as every broken link
+// to it gets the same one
+func fileHistoryHint(md *MdReport, repoPath string) string {
+	p := strings.TrimPrefix(repoPath, "/")
+	md.mu.Lock()
+	hint, ok := md.Histories[p]
+	md.mu.Unlock()
+	if ok {
+		return hint
+	}
+	hint = lookupFileHistory(md, p)
+	md.mu.Lock()
+	if md.Histories == nil {
+		md.Histories = map[string]string{}
+	}
+	md.Histories[p] = hint
+	md.mu.Unlock()
+	return hint
+}
+
+// Looks up the last commit which touched a missing file, so the commit range where it was
+// removed or renamed is known: local git history for local directories, GitHub API for repositories
+// (only if enabled, every file costs an API request)
+func lookupFileHistory(md *MdReport, p string) string {
+	if md.LocalPath != nil {
+		out, err := exec.Command("git", "-C", *md.LocalPath, "log", "-1", "--format=%h %cs", "--", p).Output()
+		if err != nil {
+			return "file doesn't exist, update or remove the link"
+		}
+		if commit := strings.Fields(string(out)); len(commit) == 2 {
+			return "file was removed or renamed in commit range " + commit[0] + "..HEAD (last changed on " + commit[1] + ")"
+		}
+		return "file doesn't exist and has never been committed"
+	}
+
+	if !md.Options.HistoryHints || md.Repository.HTMLURL == nil || md.Repository.DefaultBranch == nil || !isGithubUrl(*md.Repository.HTMLURL) {
+		return "file doesn't exist, update or remove the link"
+	}
+	ownerRepo := strings.TrimPrefix(*md.Repository.HTMLURL, "https://github.com/")
+	ref := md.Repository.ref()
+	resp, err := githubGet("https://api.github.com/repos/"+ownerRepo+"/commits?per_page=1&sha="+url.QueryEscape(ref)+"&path="+url.QueryEscape(p), md.Options.githubToken())
+	if err != nil {
+		return "file doesn't exist, update or remove the link"
+	}
+	defer resp.Body.Close()
+	var commits []struct {
+		Sha    string `json:"sha"`
+		Commit struct {
+			Committer struct {
+				Date string `json:"date"`
+			} `json:"committer"`
+		} `json:"commit"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&commits) != nil {
+		return "file doesn't exist, update or remove the link (git history is not available: response " + strconv.Itoa(resp.StatusCode) + ")"
+	}
+	if len(commits) == 0 {
+		return "file doesn't exist in " + ref + " history, the link might have a typo"
+	}
+	sha := commits[0].Sha
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+	date, _, _ := strings.Cut(commits[0].Commit.Committer.Date, "T")
+	return "file was removed or renamed in commit range " + sha + ".." + ref + " (last changed on " + date + ")"
+}
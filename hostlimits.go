@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fixed limits of a destination host: concurrent requests and request rate (requests per second, 0 - unlimited)
+type hostLimit struct {
+	concurrency int
+	rate        float64
+}
+
+// Returns minimal interval between requests of the limit's rate
+func (l hostLimit) interval() time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / l.rate)
+}
+
+// Parses per-host limits (github.com=2:1 - 2 concurrent requests, 1 request per second). Rate is optional,
+// "*" sets limits of all other hosts
+func parseHostLimits(rules []string) (map[string]hostLimit, error) {
+	parsed := map[string]hostLimit{}
+	for _, rule := range rules {
+		host, value, found := strings.Cut(rule, "=")
+		if !found || host == "" {
+			return nil, errors.New("[ERR] Host limit " + rule + " must be host=concurrency[:rate] (e.g. github.com=2:1)")
+		}
+		concurrency, rate, _ := strings.Cut(value, ":")
+		var limit hostLimit
+		var err error
+		if limit.concurrency, err = strconv.Atoi(concurrency); err != nil || limit.concurrency < 1 {
+			return nil, errors.New("[ERR] Concurrency of " + host + " must be a positive number")
+		}
+		if rate != "" {
+			if limit.rate, err = strconv.ParseFloat(rate, 64); err != nil || limit.rate <= 0 {
+				return nil, errors.New("[ERR] Request rate of " + host + " must be a positive number of requests per second")
+			}
+		}
+		parsed[strings.ToLower(host)] = limit
+	}
+	return parsed, nil
+}
+
+// Returns limit of host: limit of the host or its parent domain, "*" limit otherwise
+func matchHostLimit(limits map[string]hostLimit, host string) (hostLimit, bool) {
+	for h := strings.ToLower(host); h != ""; {
+		if l, ok := limits[h]; ok {
+			return l, true
+		}
+		_, parent, found := strings.Cut(h, ".")
+		if !found {
+			break
+		}
+		h = parent
+	}
+	l, ok := limits["*"]
+	return l, ok
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// href and src attributes of any tag
+var htmlAttributeLink = regexp.MustCompile(`(?is)<([a-z][a-z0-9-]*)\s[^>]*?\b(?:href|src)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+
+// HTML comment
+var htmlComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// Returns href and src attributes of an HTML document (anchors, images, stylesheets, scripts, frames).
+// Comments, empty, javascript: and data: URLs are skipped
+func htmlDocumentLinks(content []byte) []referenceLink {
+	var comments []referenceLink
+	for _, loc := range htmlComment.FindAllIndex(content, -1) {
+		comments = append(comments, referenceLink{offset: loc[0], end: loc[1]})
+	}
+	var links []referenceLink
+	for _, m := range htmlAttributeLink.FindAllSubmatchIndex(content, -1) {
+		if isCovered(comments, m[0]) {
+			continue
+		}
+		tag := strings.ToLower(string(content[m[2]:m[3]]))
+		var target string
+		for i := 4; i < len(m); i += 2 {
+			if m[i] >= 0 {
+				target = strings.TrimSpace(html.UnescapeString(string(content[m[i]:m[i+1]])))
+			}
+		}
+		scheme := linkScheme(target)
+		if target == "" || scheme == "javascript" || scheme == "data" {
+			continue
+		}
+		links = append(links, referenceLink{id: tag, target: "[" + tag + "](" + target + ")", offset: m[0], end: m[1], image: tag == "img"})
+	}
+	return links
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"mime"
+	"strings"
+)
+
+// Returns true if response's Content-Type is an image. Generic binary type is accepted too,
+// because some storages serve every file as a download
+func isImageType(contentType string) bool {
+	media, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		media = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return strings.HasPrefix(media, "image/") || media == "application/octet-stream"
+}
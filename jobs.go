@@ -0,0 +1,297 @@
+//go:build !noserver && !minimal
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Job states
+const (
+	jobQueued  = "queued"
+	jobRunning = "running"
+	jobDone    = "done"
+	jobFailed  = "failed"
+)
+
+var errQueueFull = errors.New("job queue is full")
+
+// Queued check request
+type Job struct {
+	ID         string    `json:"id"`
+	Account    string    `json:"account"`
+	Repository string    `json:"repository,omitempty"`
+	Profile    string    `json:"profile,omitempty"`
+	Status     string    `json:"status"`
+	Attempts   int       `json:"attempts"`
+	Error      string    `json:"error,omitempty"`
+	Created    time.Time `json:"created"`
+	Updated    time.Time `json:"updated"`
+}
+
+// Persistent job queue. Every job is stored as <id>.json key (and <id>.report.json when finished),
+// so queued and interrupted jobs are picked up again after restart
+type JobQueue struct {
+	Store      Storage
+	Workers    int
+	MaxRetries int
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	pending chan string
+}
+
+// Loads stored jobs. Unfinished jobs are queued again
+func openJobQueue(store Storage, workers, maxRetries, size int) (*JobQueue, error) {
+	q := &JobQueue{Store: store, Workers: workers, MaxRetries: maxRetries, jobs: map[string]*Job{}, pending: make(chan string, size)}
+	keys, err := store.List("")
+	if err != nil {
+		return nil, err
+	}
+	var unfinished []*Job
+	for _, key := range keys {
+		if !strings.HasSuffix(key, ".json") || strings.HasSuffix(key, ".report.json") || strings.Contains(key, "/") {
+			continue
+		}
+		job, err := q.load(key)
+		if err != nil {
+			log.Println("[ERR] Couldn't load job "+key+":", err)
+			continue
+		}
+		q.jobs[job.ID] = job
+		if job.Status == jobQueued || job.Status == jobRunning {
+			unfinished = append(unfinished, job)
+		}
+	}
+	sort.Slice(unfinished, func(i, j int) bool { return unfinished[i].Created.Before(unfinished[j].Created) })
+	for _, job := range unfinished {
+		job.Status = jobQueued
+		select {
+		case q.pending <- job.ID:
+		default:
+			log.Println("[ERR] Job queue is full, job " + job.ID + " is left unprocessed")
+		}
+	}
+	return q, nil
+}
+
+// Reads stored job
+func (q *JobQueue) load(key string) (*Job, error) {
+	content, err := q.Store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	job := new(Job)
+	return job, json.Unmarshal(content, job)
+}
+
+// Stores job's state. Caller must hold q.mu
+func (q *JobQueue) save(job *Job) {
+	job.Updated = time.Now().UTC()
+	content, _ := json.MarshalIndent(job, "", "  ")
+	if err := q.Store.Put(job.ID+".json", content); err != nil {
+		log.Println("[ERR] Couldn't store job "+job.ID+":", err)
+	}
+}
+
+// Adds a new job. Fails if too many jobs are already waiting
+func (q *JobQueue) Add(account, repo, profile string) (*Job, error) {
+	id := make([]byte, 8)
+	rand.Read(id)
+	now := time.Now().UTC()
+	job := &Job{ID: now.Format("20060102150405") + "-" + hex.EncodeToString(id), Account: account, Repository: repo, Profile: profile, Status: jobQueued, Created: now}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	select {
+	case q.pending <- job.ID:
+	default:
+		return nil, errQueueFull
+	}
+	q.jobs[job.ID] = job
+	q.save(job)
+	return job, nil
+}
+
+// Returns a copy of job's state. Jobs, which aren't known to this process, are read from
+// the storage, so any server sharing it can report them
+func (q *JobQueue) Get(id string) (Job, bool) {
+	q.mu.Lock()
+	job, ok := q.jobs[id]
+	q.mu.Unlock()
+	if ok {
+		return *job, true
+	}
+	if strings.ContainsAny(id, "/.") {
+		return Job{}, false
+	}
+	stored, err := q.load(id + ".json")
+	if err != nil {
+		return Job{}, false
+	}
+	return *stored, true
+}
+
+// Changes job's state and stores it
+func (q *JobQueue) update(id string, change func(*Job)) Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job := q.jobs[id]
+	change(job)
+	q.save(job)
+	return *job
+}
+
+// Returns key of job's JSON report
+func reportKey(id string) string {
+	return id + ".report.json"
+}
+
+// Runs workers, which process jobs until ctx is cancelled. Failed jobs are retried with exponential delay
+func (s *Server) processJobs(ctx context.Context) {
+	q := s.Queue
+	for i := 0; i < q.Workers; i++ {
+		go func() {
+			for {
+				var id string
+				select {
+				case <-ctx.Done():
+					return
+				case id = <-q.pending:
+				}
+				// Don't start new jobs while draining, they stay queued until restart
+				if atomic.LoadInt32(&s.ready) == 0 {
+					return
+				}
+				s.jobs.Add(1)
+				s.runJob(id)
+				s.jobs.Done()
+			}
+		}()
+	}
+}
+
+// Processes a single job
+func (s *Server) runJob(id string) {
+	q := s.Queue
+	job := q.update(id, func(j *Job) {
+		j.Status = jobRunning
+		j.Attempts++
+	})
+	profile, ok := s.profile(job.Profile)
+	var err error
+	if !ok {
+		err = errors.New("unknown profile " + job.Profile)
+	} else {
+		var reports []*MdReport
+		var elapsed time.Duration
+		if reports, elapsed, err = s.runProfileCheck(job.Account, job.Repository, job.Profile, profile); err == nil {
+			var body bytes.Buffer
+			if err = writeJsonReport(reports, &body, elapsed); err == nil {
+				err = q.Store.Put(reportKey(id), body.Bytes())
+			}
+		}
+	}
+	if err == nil {
+		q.update(id, func(j *Job) {
+			j.Status = jobDone
+			j.Error = ""
+		})
+		return
+	}
+	if !ok || job.Attempts > q.MaxRetries {
+		q.update(id, func(j *Job) {
+			j.Status = jobFailed
+			j.Error = err.Error()
+		})
+		return
+	}
+	// Requeue after a delay, which doubles with every attempt
+	q.update(id, func(j *Job) {
+		j.Status = jobQueued
+		j.Error = err.Error()
+	})
+	delay := time.Duration(1<<uint(job.Attempts-1)) * 10 * time.Second
+	time.AfterFunc(delay, func() {
+		select {
+		case q.pending <- id:
+		default:
+			q.update(id, func(j *Job) {
+				j.Status = jobFailed
+				j.Error = errQueueFull.Error()
+			})
+		}
+	})
+}
+
+// POST /jobs?account=<name>[&repository=<name>][&profile=<name>] queues a check and responds with the job.
+// GET /jobs/<id> returns job's state, GET /jobs/<id>/report returns JSON report of a finished job
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/jobs"), "/")
+	if id == "" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		account, repo := r.URL.Query().Get("account"), r.URL.Query().Get("repository")
+		if account == "" {
+			http.Error(w, "account parameter is required", http.StatusBadRequest)
+			return
+		}
+		name, _, ok := s.requestProfile(r)
+		if !ok {
+			http.Error(w, "unknown profile", http.StatusNotFound)
+			return
+		}
+		if atomic.LoadInt32(&s.ready) == 0 {
+			http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+			return
+		}
+		job, err := s.Queue.Add(account, repo, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/jobs/"+job.ID)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(job)
+		return
+	}
+
+	report := strings.HasSuffix(id, "/report")
+	id = strings.TrimSuffix(id, "/report")
+	job, ok := s.Queue.Get(id)
+	// Jobs of other profiles don't exist for profile-scoped keys
+	if !ok || !requestKey(r).allowsProfile(job.Profile) {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !report {
+		json.NewEncoder(w).Encode(job)
+		return
+	}
+	if job.Status != jobDone {
+		http.Error(w, "job is "+job.Status, http.StatusConflict)
+		return
+	}
+	content, err := s.Queue.Store.Get(reportKey(id))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write(content)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// Result of a link request, shared by every occurrence of the link
+type cachedResult struct {
+	done      chan struct{} // closed when the request is completed
+	r         *req.Response
+	ok        bool
+	throttled time.Duration
+	err       error
+}
+
+// Results of external links by normalized URL, so each unique link is requested once per run.
+// Links verified by earlier runs are loaded from persistent store (if it's set)
+type resultCache struct {
+	mu      sync.Mutex
+	results map[string]*cachedResult
+	stored  *linkStore
+}
+
+func newResultCache(stored *linkStore) *resultCache {
+	return &resultCache{results: map[string]*cachedResult{}, stored: stored}
+}
+
+// Returns cache key of URL: scheme and host are lower-cased, default port, fragment
+// (it's never sent to servers) and trailing slash of an empty path are dropped
+func normalizeUrl(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+	u.Scheme, u.Host, u.Fragment, u.RawFragment = strings.ToLower(u.Scheme), strings.ToLower(u.Host), "", ""
+	if (u.Scheme == "http" && u.Port() == "80") || (u.Scheme == "https" && u.Port() == "443") {
+		u.Host = u.Hostname()
+	}
+	if u.Path == "/" && u.RawQuery == "" {
+		u.Path = ""
+	}
+	return u.String()
+}
+
+// Requests link once per run. Occurrences of a link, which is being requested, wait for its result.
+// Only the first occurrence reports throttled time. Nil cache requests link every time
+func (c *resultCache) requestLink(link string, opts *Options) (*req.Response, bool, time.Duration, error) {
+	if c == nil {
+		return requestLink(link, opts)
+	}
+	key := normalizeUrl(link)
+	c.mu.Lock()
+	if cached, found := c.results[key]; found {
+		c.mu.Unlock()
+		<-cached.done
+		return cached.r, cached.ok, 0, cached.err
+	}
+	cached := &cachedResult{done: make(chan struct{})}
+	c.results[key] = cached
+	c.mu.Unlock()
+	if r, found := c.stored.load(key); found {
+		cached.r, cached.ok = r, true
+	} else if cached.r, cached.ok, cached.throttled, cached.err = requestLink(link, opts); cached.ok && !opts.insecureTls() {
+		// Links verified without certificate checks must not be skipped by later (verifying) runs
+		c.stored.store(key, cached.r)
+	}
+	close(cached.done)
+	return cached.r, cached.ok, cached.throttled, cached.err
+}
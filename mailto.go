@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+// Validates addresses of a mailto: link: syntax of every address and mail exchanger of its domain
+// (MX record, or an address record if the domain has no MX). Returns finding's code and hint
+func checkMailto(link string) (ok bool, reason, hint, code string) {
+	_, to, _ := strings.Cut(strings.TrimSpace(link), ":")
+	to, _, _ = strings.Cut(to, "?")
+	to, err := url.PathUnescape(to)
+	if err != nil || to == "" {
+		return false, "", "mailto link has no address, add it or remove the link", codeInvalidEmail
+	}
+	for _, address := range strings.Split(to, ",") {
+		parsed, err := mail.ParseAddress(strings.TrimSpace(address))
+		if err != nil {
+			return false, err.Error(), "address " + address + " is not valid, fix its syntax", codeInvalidEmail
+		}
+		domain := parsed.Address[strings.LastIndex(parsed.Address, "@")+1:]
+		if err := lookupMailDomain(domain); err != nil {
+			return false, err.Error(), "domain " + domain + " doesn't receive e-mail (no MX or address record), update or remove the address", codeMailDomain
+		}
+	}
+	return true, "", "", ""
+}
+
+// Returns error if domain has no mail exchanger. Null MX (RFC 7505) means the domain accepts no mail
+func lookupMailDomain(domain string) error {
+	mx, err := net.LookupMX(domain)
+	if err == nil && len(mx) > 0 {
+		if len(mx) == 1 && (mx[0].Host == "." || mx[0].Host == "") {
+			return errors.New("domain " + domain + " has null MX record")
+		}
+		return nil
+	}
+	var dnsErr *net.DNSError
+	if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+		return err
+	}
+	// Domains without MX records receive mail at their address (RFC 5321)
+	_, err = net.LookupHost(domain)
+	return err
+}
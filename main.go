@@ -2,18 +2,24 @@ package main
 
 import (
 	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
 	"encoding/json"
+	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
-	"net"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"text/template"
+	"time"
 
 	"github.com/imroc/req/v3"
 	"github.com/urfave/cli/v2"
@@ -25,61 +31,210 @@ var (
 
 const (
 	repoMdStruct = `
-## [{{.Repository.Name}}]({{.Repository.HTMLURL}})`
+## [{{.Repository.Name}}]({{.RepoUrl}})`
 	repoCliStruct = `
-## [{{.Repository.Name}}]({{.Repository.HTMLURL}})`
+## [{{.Repository.Name}}]({{.RepoUrl}})`
 	repoErrStruct  = ` - {{.State}}`
+	repoWarnStruct = `{{range .Warnings}}
+* [WRN] {{.Code}} {{.Message}}{{end}}{{if .Sample}}
+* [INF] {{.Sample}}{{end}}`
 	fileHeadStruct = `
-* {{.Repository.HTMLURL}}/blob/{{.Repository.DefaultBranch}}/`
-	fileStruct = `{{.Path}}
+* {{.FilesUrl}}`
+	fileStruct = `{{.Path}}{{if .DisplayPath}} ({{.DisplayPath}}){{end}}{{if .Generated}} (generated, fix it in the generator){{end}}
 
-| URL | State |
-| --- | --- |
+| URL | State | Hint |
+| --- | --- | --- |
 `
-	linkMdStruct = `| {{.Link}} | {{.State}} |
+	linkMdStruct = `| {{.Link}} | {{.State}}{{if .Code}} {{.Code}}{{end}}{{if .Reason}} {{.Reason}}{{end}}{{if .IsWarning}} (warning){{end}} | {{if .Hint}}{{.Hint}}{{end}}{{if .Blame}} ({{.Blame}}){{end}} |
 `
-	linkCliStruct = `| {{.Link}} | {{.State}} |
+	linkCliStruct = `| {{.Link}} | {{.State}}{{if .Code}} {{.Code}}{{end}}{{if .Reason}} {{.Reason}}{{end}}{{if .IsWarning}} (warning){{end}} | {{if .Hint}}{{.Hint}}{{end}}{{if .Blame}} ({{.Blame}}){{end}} |
 `
 )
 
 type Repository struct {
 	// Part of Github API response strutures
 	// https://github.com/google/go-github/blob/2d872b40760dcf7080786ece0a4735509ff071f4/github/repos.go#L28
-	Name          *string `json:"name,omitempty"`
-	URL           *string `json:"url,omitempty"`
-	Fork          *bool   `json:"fork,omitempty"`
-	Disabled      *bool   `json:"disabled,omitempty"`
-	Archived      *bool   `json:"archived,omitempty"`
-	CloneURL      *string `json:"clone_url,omitempty"`
-	HTMLURL       *string `json:"html_url,omitempty"`
-	DefaultBranch *string `json:"default_branch,omitempty"`
-	Size          *int    `json:"size,omitempty"`
+	Name          *string  `json:"name,omitempty"`
+	URL           *string  `json:"url,omitempty"`
+	Fork          *bool    `json:"fork,omitempty"`
+	Disabled      *bool    `json:"disabled,omitempty"`
+	Archived      *bool    `json:"archived,omitempty"`
+	CloneURL      *string  `json:"clone_url,omitempty"`
+	HTMLURL       *string  `json:"html_url,omitempty"`
+	DefaultBranch *string  `json:"default_branch,omitempty"`
+	Size          *int     `json:"size,omitempty"`
+	HasWiki       *bool    `json:"has_wiki,omitempty"`
+	Private       *bool    `json:"private,omitempty"`
+	Topics        []string `json:"topics,omitempty"`
 	// Custom fields
-	WebUrl *string // for relative paths check
+	WebUrl      *string // for relative paths check
+	TreeUrl     *string // for directory links check
+	ArchiveUrl  *string // zip archive of checked ref
+	Ref         *string // checked branch, tag or commit (default branch if not set)
+	FlatArchive bool    // archive has no top folder
+	RefCommit   bool    // checked ref is a commit, not a branch or tag
+}
+
+// Options which change how links are resolved and checked
+type Options struct {
+	GithubCompat       bool
+	Outputs            []string `json:"-"`
+	PathStyle          string
+	LocalPath          string `json:"-"` // local directory to check instead of GitHub repositories
+	File               string `json:"-"` // single markdown file to check
+	BaseUrl            string // where relative links of a single file are resolved
+	GitUrl             string `json:"-"` // any Git repository to clone and check
+	WebBase            string // where relative links of a cloned repository are resolved
+	SkipGenerated      bool
+	GeneratedPaths     []string          // generator output path patterns
+	Symlinks           string            // symlinked files policy: follow or skip
+	ContextRules       map[string]string // failed links severity per link context
+	Method             string            // request method of external links: head (GET fallback) or get
+	Timeout            time.Duration     // deadline of a link check (0 - none)
+	MaxAttempts        int               // attempts of a link check, which fails transiently (429, 5xx, timeout)
+	RetryBackoff       time.Duration     // pause before the first retry, doubled by every next one
+	MethodRules        map[string]string // request methods per domain
+	Slug               string            // heading slug algorithm for anchor checks (github if empty)
+	ExtractWorkers     int               // markdown parsing concurrency (number of CPUs by default)
+	CheckWorkers       int               // link checking concurrency per repository
+	Concurrency        int               // link checking concurrency of all repositories
+	checks             checkPool         // slots of concurrent link checks
+	results            *resultCache      // results of requested links, shared by their occurrences
+	cache              *linkStore        // results of links verified by earlier runs
+	CacheTtl           time.Duration     // time, which verified links aren't rechecked for
+	DnsTimeout         time.Duration     // deadline of a host lookup (0 - none)
+	dns                *dnsCache         // host lookups shared by all checks
+	memory             *memoryBudget     // limit of file contents held in memory
+	WorkDir            string            `json:"-"` // where archives are downloaded
+	Token              string            `json:"-"` // GitHub API token
+	Provider           string            // repository hosting provider (github by default)
+	ProviderUrl        string            // self-hosted provider's server URL
+	ProviderToken      string            `json:"-"` // token of a provider other than GitHub
+	Org                bool              // account is a GitHub organization
+	IncludeWikis       bool              // check repositories' wikis too
+	Gists              bool              // check user's gists instead of repositories
+	Ref                string            // checked branch, tag or commit instead of default branch
+	Pr                 int               // check only markdown files changed by the pull request
+	Record             string            // directory, which HTTP interactions are recorded to
+	Replay             string            // directory, which HTTP interactions are replayed from (no network access)
+	recorder           *httpRecorder
+	Http3              bool             // check links over HTTP/3 first
+	Insecure           bool             // don't verify TLS certificates of checked links
+	CaCert             string           // PEM file of additionally trusted CA certificates
+	MinTlsVersion      string           // minimal TLS version of checked links
+	tls                *tls.Config      // TLS configuration of link check clients (nil - defaults)
+	MaxRedirects       int              // redirects followed by a link check (0 - none)
+	WarnRedirects      bool             // permanent redirects (301/308) are warnings with the final URL
+	ci                 *ciEnvironment   // CI system, which checkout is checked
+	app                *githubApp       // GitHub App, which authenticates as its installation instead of token
+	throttle           *adaptiveLimiter // per-host concurrency, adjusted by rate limit responses
+	Project            string           // GitHub Project (<owner>/<number>), which broken links are exported to
+	ProjectItems       string           // project item per broken link (link) or per repository (repo)
+	ProjectField       string           // project's single select status field
+	CheckBareUrls      bool             // check autolinks and plain URLs in text too
+	CheckImageTypes    bool             // images must be served with an image Content-Type
+	CheckCodeLinks     bool             // check links in code blocks and code spans too
+	CheckFrontMatter   bool             // check URL-valued fields of front matter
+	CheckHtml          bool             // check href and src attributes of HTML files too
+	Extensions         []string         // extensions of checked files, which replace supported documents
+	LinkPatterns       []*regexp.Regexp // custom patterns of links, which are extracted in addition to built-in ones
+	Orphans            bool             // warn about markdown files, which no other document links to
+	EntryPoints        []string         // markdown files, which need no inbound links (README.md by default)
+	VersionedDocs      bool             // check that versioned docs (docs/v1, docs/v2) don't link across versions
+	Schemes            []string         // URL schemes of checked links (http and https by default)
+	Fix                bool             // rewrite broken links of local directories to their suggested targets
+	VerifyMailto       bool             // validate mailto: addresses and their domains' mail exchangers
+	HistoryHints       bool             // look up commit ranges of missing repository files via GitHub API
+	Preview            bool             // print fixes as a diff (fixed files are written to a temporary directory)
+	FixPr              bool             // open a pull request with fixes per checked GitHub repository
+	SkipSchemes        []string         // URL schemes of links, which are counted as skipped (mailto, tel, ftp, irc by default)
+	StrictPlaceholders bool             // report placeholder links (example domains, template variables) instead of skipping them
+	MaxErrors          int              // exit code is 1 if there are more errors (negative - never)
+	MaxWarnings        int              // exit code is 1 if there are more warnings (negative - never)
+	Sample             []string         // sampling rates: default (e.g. 10%) and per-domain (<domain>=<rate>)
+	sampling           *sampling
+	DownloadStagger    time.Duration   // minimal interval between archive download starts
+	downloads          *downloadGate   // spaces downloads and pauses them while rate limited
+	visibility         *repoVisibility // private repositories, which public docs might link to
+	clients            *webClients     // shared link check clients
+	MaxRepos           int             // upper limit of checked repositories (0 - unlimited)
+	Topics             []string        // check only repositories with any of these topics
+	RepoInclude        []string        // check only repositories, which names match any of these glob patterns
+	RepoExclude        []string        // skip repositories, which names match any of these glob patterns
+	IncludeForks       bool            // check forked repositories
+	IncludeArchived    bool            // check archived repositories
+	IncludeDisabled    bool            // check disabled repositories
+	Egress             *EgressPolicy
+	SignKey            ed25519.PrivateKey `json:"-"` // JSON reports signing key
 }
 
 // Checked URL structure
 type MdLink struct {
-	Link    *string
-	State   *int
-	Succeed *bool
+	Link      *string
+	State     *int
+	Succeed   *bool
+	Duration  *time.Duration
+	Line      *int
+	Column    *int
+	Reason    *string        // why the link check failed, if there was no HTTP response
+	Hint      *string        // how to fix the failed link
+	Blame     *BlameInfo     // who changed the failed link's line last (local git working tree)
+	Context   *string        // where the link is found: prose, badge, table, footnote or heading
+	Severity  *string        // error, or warning if failure doesn't fail the check
+	Code      *string        // stable error code of the failed check (see errorCatalogue)
+	Moved     *string        // suggested target of a missing file's link, which was likely moved (applied by fix mode)
+	Redirects []Redirect     // redirects, which the link check followed
+	Throttled *time.Duration // how long the check waited for the host's rate limit (Retry-After, per-host limits)
 }
 
 // Checked MD file matched URL and path to the file
 type MdFile struct {
-	Path     *string
-	LinkList *[]MdLink
+	Path        *string
+	LinkList    *[]MdLink
+	DisplayPath *string
+	Generated   bool // file has auto-generation markers, so it must be fixed in the generator
+}
+
+// Problem of a repository, which doesn't fail the check (symlink loops, case duplicates etc.)
+type ReportWarning struct {
+	Code    string `json:"code"` // stable code (see errorCatalogue)
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"` // file, which the warning is about
+	Line    int    `json:"line,omitempty"`
+}
+
+// Returns warning's line, the first one if the warning is about a whole file
+func (w ReportWarning) line() int {
+	if w.Line < 1 {
+		return 1
+	}
+	return w.Line
 }
 
 // Generated reports structure
 type MdReport struct {
-	Repository *Repository
-	MdFileList *[]MdFile
-	ZipUrl     *string
-	ZipName    *string
-	ZipPath    *string
-	State      *string
-	AllLinksOK *bool
+	Repository  *Repository
+	MdFileList  *[]MdFile
+	ZipUrl      *string
+	ZipName     *string
+	ZipPath     *string
+	State       *string
+	AllLinksOK  *bool
+	Options     *Options
+	Duration    *time.Duration
+	ArtifactUrl *string                    // stored report's URL (server mode)
+	LocalPath   *string                    // checked local directory (instead of repository archive)
+	File        *string                    // single checked file (relative to LocalPath)
+	Files       []SourceFile               // listed source files, if they aren't read from archive or local directory
+	Warnings    []ReportWarning            // problems, which don't fail the check
+	Sample      *SampleStats               // link counts, if only a sample of links is checked
+	Skipped     int                        // ignored links and links left out of the sample
+	ErrorCodes  []string                   // codes of execution errors (download, archive, read etc.)
+	Sources     map[string]SourceFile      // checked files by their repository paths
+	Anchors     map[string]map[string]bool // heading anchors of markdown files (computed on demand)
+	Histories   map[string]string          // history hints of missing files (looked up on demand)
+	mu          sync.Mutex                 // guards state, warnings and anchors while files are checked concurrently
+	Tree        map[string]bool            // archive paths (relative to repository root), true for directories
 }
 
 type MdReportList struct {
@@ -89,9 +244,14 @@ type MdReportList struct {
 
 // Writes results in specified format
 func generateReport(md *MdReport, out *os.File) {
-	var linkStruct, repoStruct string
 	outInfo, _ := out.Stat()
-	if outInfo.Name() != "stdout" && getFileExtension(outInfo.Name()) == "md" {
+	writeRepoReport(md, out, outInfo.Name() != "stdout" && getFileExtension(outInfo.Name()) == "md")
+}
+
+// Writes repository's report as markdown or console text
+func writeRepoReport(md *MdReport, out io.Writer, markdown bool) {
+	var linkStruct, repoStruct string
+	if markdown {
 		linkStruct = linkMdStruct
 		repoStruct = repoMdStruct
 	} else {
@@ -103,8 +263,14 @@ func generateReport(md *MdReport, out *os.File) {
 	if md.State != nil {
 		t = template.Must(template.New("repoErrStruct").Parse(repoErrStruct))
 		t.Execute(out, md)
-	} else if len(*md.MdFileList) != 0 {
+	}
+	t = template.Must(template.New("repoWarnStruct").Parse(repoWarnStruct))
+	t.Execute(out, md)
+	if md.State == nil && len(*md.MdFileList) != 0 {
 		for _, file := range *md.MdFileList {
+			if !file.hasFailures() {
+				continue
+			}
 			t = template.Must(template.New("fileHead").Parse(fileHeadStruct))
 			t.Execute(out, md)
 			if !*md.AllLinksOK {
@@ -121,214 +287,593 @@ func generateReport(md *MdReport, out *os.File) {
 	}
 }
 
+// Returns true if at least one link in the file is broken
+func (f *MdFile) hasFailures() bool {
+	for _, link := range *f.LinkList {
+		if !*link.Succeed {
+			return true
+		}
+	}
+	return false
+}
+
 func getFileExtension(s string) string {
 	s = strings.ToLower(s)
 	ext := strings.Split(s, ".")
 	return ext[len(ext)-1]
 }
 
-func checkUrl(url string, web *req.Client) (response *req.Response, ok bool) {
-	response, err := web.R().Get(url)
+// Checks URL using HTTP/3. Servers, which can't be reached over QUIC, are checked again over HTTP/1.1 or HTTP/2
+func checkUrlHttp3(url string, opts *Options) (*req.Response, bool, error) {
+	r, ok, err := checkUrl(url, opts.webClient(true), opts.requestMethod(url), opts.Timeout)
+	if err == nil {
+		return r, ok, err
+	}
+	return checkUrl(url, opts.webClient(false), opts.requestMethod(url), opts.Timeout)
+}
+
+// Requests URL with HEAD (if method is head) or GET. Servers, which reject HEAD requests, are requested with GET.
+// Both requests must complete within timeout (if it's set)
+func checkUrl(url string, web *req.Client, method string, timeout time.Duration) (response *req.Response, ok bool, err error) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if method == methodHead {
+		if response, err = web.R().SetContext(ctx).Head(url); err != nil {
+			return response, ok, err
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusMethodNotAllowed && response.StatusCode != http.StatusForbidden {
+			return response, response.StatusCode == http.StatusOK, nil
+		}
+	}
+	response, err = web.R().SetContext(ctx).Get(url)
 	if err != nil {
-		return response, ok
+		return response, ok, err
 	}
 	defer response.Body.Close()
 	switch response.StatusCode {
 	case 200:
 		ok = true
 	}
-	return response, ok
+	return response, ok, nil
 
 }
 
-// Tries to validate markdown URL
-func checkMdLink(md *MdReport, l, rpath, fpath string) (result int, ok bool) {
-	var webclient = req.C()
+// Tries to validate markdown URL. Image links are validated by their Content-Type too (if enabled)
+func checkMdLink(md *MdReport, l, rpath, fpath string, image bool) (result int, ok bool, reason, hint, code string, throttled time.Duration, redirects []Redirect) {
 	var r *req.Response
-	var url string
-	// Delete last elemnt, which is a brace
-	l = l[:len(l)-1]
-	// Delete part containing square brackets and brace, which comes before a link
-	l = l[len(regexp.MustCompile(`(^\[(.*?)]\()`).FindString(l)):]
+	var err error
+	var url, repoPath string
+	defer func() {
+		if !ok && hint == "" {
+			hint = remediationHint(md, url, repoPath, result, r, err)
+		}
+		if !ok && code == "" {
+			code = linkErrorCode(result, repoPath, err)
+		}
+	}()
+	l = linkTarget(l)
+	// Fragment-only links point to current document's headings
+	if strings.HasPrefix(l, "#") {
+		if ok = md.hasAnchor(fpath, l[1:]); ok {
+			result = http.StatusOK
+		} else {
+			result, code = http.StatusNotFound, codeMissingAnchor
+			hint = "document has no heading with this anchor (" + md.slugName() + " slugs), update the fragment"
+		}
+		return result, ok, reason, hint, code, throttled, redirects
+	}
+	// Placeholders are left in the report only in strict mode, they are never requested
+	if isPlaceholderUrl(l) {
+		hint = "link is a placeholder (example domain or template variable), replace it with a real URL"
+		return result, ok, reason, hint, codePlaceholder, throttled, redirects
+	}
+	if linkScheme(l) == "mailto" && md.Options.VerifyMailto {
+		ok, reason, hint, code = checkMailto(l)
+		return result, ok, reason, hint, code, throttled, redirects
+	}
+	// Skipped schemes never get here, so any other scheme is unknown
+	if scheme := linkScheme(l); scheme != "" && !md.Options.checksScheme(scheme) {
+		hint = "scheme " + scheme + " is neither checked nor skipped, fix the link or add the scheme to --skip-schemes"
+		return result, ok, reason, hint, codeUnknownScheme, throttled, redirects
+	}
 	// Check if link starts with http/https
 	url = regexp.MustCompile(`(^https?:\/\/)([\da-z\.-]+)\.([a-z\.]{2,6})\/?.*`).FindString(l)
+	// Backslashes (Windows-style paths) must never leak into URLs
+	if !strings.Contains(l, ":") && url == "" {
+		l = normalizeSlashes(l)
+	}
+	// GitHub never treats schemeless links as domain names, so resolve them as repository paths
+	if md.Options != nil && md.Options.GithubCompat && !strings.Contains(l, ":") && url == "" {
+		repoPath = resolveRepoPath(l, rpath, fpath)
+		if md.checksRelativeUrls() {
+			url = resolveGithubLink(md, l, rpath, fpath)
+		}
+	}
 	// Check if a domain name is resolvable and filename extension != md -> add http protocol
 	// else -> add relative path to it
-	if fqdn, _, _ := strings.Cut(l, "/"); !strings.Contains(l, ":") && url == "" {
-		if _, err := net.LookupIP(fqdn); err == nil && getFileExtension(l) != "md" {
+	if fqdn, _, _ := strings.Cut(l, "/"); !strings.Contains(l, ":") && url == "" && repoPath == "" {
+		if _, err := md.Options.lookupIP(fqdn); err == nil && getFileExtension(l) != "md" {
 			url = "http://" + l
 		} else {
+			repoPath = resolveRepoPath(l, rpath, fpath)
 			// Check if link starts / -> absolute path is used
-			// if not -> relative path should be used
-			if l != "" && string(l[0]) == "/" {
-				url = *md.Repository.WebUrl + l
-			} else {
-				url = *md.Repository.WebUrl + rpath + l
+			// if not -> relative path should be used.
+			// Local files have no web pages (unless base URL is set), so they are validated against directory tree
+			if md.checksRelativeUrls() {
+				if l != "" && string(l[0]) == "/" {
+					url = *md.Repository.WebUrl + l
+				} else {
+					url = *md.Repository.WebUrl + rpath + l
+				}
 			}
 		}
 	}
-	// Test URL if link is not an e-mail address
-	if strings.HasPrefix(l, "mailto:") {
-		ok = true
-	} else {
-		r, ok = checkUrl(url, webclient)
+	// Fragment of a markdown file from the same archive is validated against its headings instead of requesting its page
+	if anchor, target, found := md.crossFileAnchor(l, repoPath); found {
+		if ok = md.hasAnchor(target, anchor); ok {
+			result = http.StatusOK
+		} else {
+			result, code = http.StatusNotFound, codeMissingAnchor
+			hint = target + " has no heading with this anchor (" + md.slugName() + " slugs), update the fragment"
+		}
+		return result, ok, reason, hint, code, throttled, redirects
+	}
+	if _, found := md.Tree[repoPath]; repoPath != "" && !found {
+		if actual := md.caseMismatch(repoPath); actual != "" {
+			hint = "link's case differs from " + actual + ", which breaks on case-sensitive hosting (static site generators), fix the case"
+			return http.StatusOK, false, reason, hint, codeCaseMismatch, throttled, redirects
+		}
+	}
+	// Local file link is valid if the target exists
+	if !md.checksRelativeUrls() && repoPath != "" && !md.isArchiveDir(repoPath) {
+		if _, ok = md.Tree[repoPath]; ok {
+			result = http.StatusOK
+		} else {
+			result = http.StatusNotFound
+		}
+		return result, ok, reason, hint, code, throttled, redirects
+	}
+	// Files of the archive exist on GitHub too (paths are case-sensitive), so their pages aren't requested.
+	// Targets missing in the archive (e.g. wiki or issues pages) are still checked over HTTP
+	if isDir, found := md.Tree[repoPath]; repoPath != "" && found && !isDir {
+		return http.StatusOK, true, reason, hint, code, throttled, redirects
+	}
+	// Directory link is valid only if GitHub can render a README inside it
+	if md.isArchiveDir(repoPath) {
+		if ok = md.hasArchiveReadme(repoPath); ok {
+			result = http.StatusOK
+		} else {
+			result = http.StatusNotFound
+		}
+		return result, ok, reason, hint, code, throttled, redirects
+	}
+	if err = md.Options.Egress.checkURL(url); err == nil {
+		r, ok, throttled, err = md.Options.results.requestLink(url, md.Options)
+		redirects = redirectChain(r)
+		// Permanently moved links still work, but they should be updated before the redirect is removed
+		if ok && md.Options.WarnRedirects && hasPermanentRedirect(redirects) {
+			ok, code = false, codeMovedPermanently
+			hint = "link was moved permanently, update to " + redirects[len(redirects)-1].Location
+		}
+		if delay, found := retryAfter(r, time.Now()); !ok && found && delay > maxRetryAfter {
+			hint = "server asks to retry after " + delay.Round(time.Second).String() + ", which is longer than checks wait, rerun the check later"
+		}
+		// GitHub responds 404 for private repositories, which public docs must not reference
+		if r != nil && r.Response != nil && r.StatusCode == http.StatusNotFound && repoPath == "" && !md.Repository.isPrivate() {
+			if private := md.Options.privateTarget(url); private != "" {
+				code = codePrivateTarget
+				hint = "link points to private repository " + private + ", remove the internal reference from public docs or make the target public"
+			}
+		}
+		// Removed images are often replaced by HTML error or login pages with 200 status
+		if ok && image && repoPath == "" && md.Options.CheckImageTypes {
+			if contentType := r.Header.Get("Content-Type"); !isImageType(contentType) {
+				if contentType == "" {
+					contentType = "no Content-Type"
+				}
+				ok, code = false, codeNotImage
+				hint = "image URL responds with " + contentType + " instead of an image, update or remove the image"
+			}
+		}
 	}
 
 	// Store HTTP response if there is one
 	if r != nil && r.Err == nil {
 		result = r.StatusCode
+	} else if err != nil {
+		reason = err.Error()
 	}
-	return result, ok
+	return result, ok, reason, hint, code, throttled, redirects
 }
 
-// Searches for *.md files and loads its content from *.zip archive
-func findAndCheckMdFile(md *MdReport, f *zip.File) {
-	_, fileFullPath, _ := strings.Cut(f.FileHeader.Name, "/")
-	fileRelativePath, _, _ := strings.Cut(fileFullPath, f.FileInfo().Name())
-
-	if fileRelativePath != "" {
-		fileRelativePath = "/" + fileRelativePath + "/"
-	} else {
-		fileRelativePath = "/"
-	}
-	if !f.FileInfo().IsDir() {
-		fileName := f.FileInfo().Name()
-		ext := getFileExtension(fileName)
-		// Proceed if file is not a directory and has .md extension
-		if strings.ToLower(ext) == "md" {
-			links := []MdLink{}
-			zipContent, err := f.Open()
-			if err != nil {
-				state := (*md.State + " [ERR] Couldn't open " + f.FileInfo().Name() + " file: \n\t" + err.Error())
-				md.State = &state
-				return
-			}
-			defer zipContent.Close()
+// Loads *.md file's content from *.zip archive or local directory and extracts its links.
+// Returns nil if file isn't a markdown file or it's skipped
+func extractMdFile(md *MdReport, f SourceFile) *extractedFile {
+	fileFullPath := f.Path
+	if f.IsDir {
+		return nil
+	}
+	fileName := path.Base(fileFullPath)
+	// Proceed if file is a markdown or another supported document
+	extract := md.Options.documentExtractor(fileName)
+	if extract == nil {
+		return nil
+	}
+	// Bounds memory held by file contents of all extraction workers
+	release := md.Options.memory.acquire(f.Size)
+	defer release()
+	zipContent, err := f.Open()
+	if err != nil {
+		md.addError(codeReadFailed, "Couldn't open "+fileName+" file: \n\t"+err.Error())
+		return nil
+	}
+	defer zipContent.Close()
 
-			content, err := ioutil.ReadAll(zipContent)
-			if err != nil {
-				state := (*md.State + " [ERR] Couldn't load " + f.FileInfo().Name() + ": \n\t" + err.Error())
-				md.State = &state
-				return
-			}
-			// Use regexp for matching Markdown URL
-			matches := regexp.MustCompile(`\[[^\[\]]*?\]\(.*?\)|^\[*?\]\(.*?\)`).FindAll(content, -1)
-			for _, val := range matches {
-				url := string(val)
-				state, ok := checkMdLink(md, url, fileRelativePath, fileFullPath)
-				if !ok {
-					*md.AllLinksOK = false
-					mdLinkVal := MdLink{&url, &state, &ok}
-					links = append(links, mdLinkVal)
-				}
+	content, err := readSized(zipContent, f.Size)
+	if err != nil {
+		md.addError(codeReadFailed, "Couldn't load "+fileName+": \n\t"+err.Error())
+		return nil
+	}
+	content, encoding := decodeContent(content)
+	if encoding != "utf-8" && encoding != "utf-8-bom" {
+		md.addWarning(ReportWarning{Code: codeTranscoded, Path: fileFullPath, Message: fileFullPath + " is " + encoding + " encoded, links were checked after conversion to UTF-8"})
+	}
+	file := &extractedFile{path: fileFullPath, generated: isGeneratedFile(fileFullPath, content, md.Options.GeneratedPaths)}
+	if file.generated && md.Options.SkipGenerated {
+		return nil
+	}
+	fileConfig := parseFileConfig(content)
+	if fileConfig.Skip {
+		return nil
+	}
+	found, undefined := extract(content, md.Options)
+	// Custom patterns skip found links and, the same as markdown links, examples in code
+	if len(md.Options.LinkPatterns) > 0 {
+		covered := append([]referenceLink{}, found...)
+		if !md.Options.CheckCodeLinks && md.Options.isMarkdown(fileFullPath) {
+			for _, span := range markdownCode(content) {
+				covered = append(covered, referenceLink{offset: span[0], end: span[1]})
 			}
-			if len(links) > 0 {
-				if md.MdFileList == nil {
-					file := []MdFile{{&fileFullPath, &links}}
-					md.MdFileList = &file
-				} else {
-					file := MdFile{&fileFullPath, &links}
-					*md.MdFileList = append(*md.MdFileList, file)
-				}
+		}
+		found = append(found, patternLinks(content, md.Options.LinkPatterns, covered)...)
+	}
+	// Front matter (Hugo, Jekyll) is metadata, not rendered text. Its URL-valued fields are checked only if enabled
+	frontMatter := frontMatterEnd(content)
+	if frontMatter > 0 {
+		kept := found[:0]
+		for _, link := range found {
+			if link.offset >= frontMatter {
+				kept = append(kept, link)
 			}
 		}
+		found = kept
+		if md.Options.CheckFrontMatter {
+			found = append(found, frontMatterUrls(content[:frontMatter])...)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].offset < found[j].offset })
+	for _, ref := range undefined {
+		if ref.offset < frontMatter {
+			continue
+		}
+		line, _ := linePosition(content, ref.offset)
+		md.addWarning(ReportWarning{Code: codeUndefinedRef, Path: fileFullPath, Line: line, Message: fmt.Sprintf("%s:%d reference [%s] has no definition, it's rendered as plain text", fileFullPath, line, ref.id)})
+	}
+	for _, loc := range found {
+		url := loc.target
+		context := linkContext(content, loc.offset)
+		severity := md.Options.contextSeverity(context)
+		// Placeholders (example.com, <ORG>) are intentional, unless strict mode reports them
+		placeholder := !md.Options.StrictPlaceholders && isPlaceholderUrl(linkTarget(url))
+		if fileConfig.ignored(url) || severity == severityIgnore || placeholder || md.Options.skipsScheme(linkScheme(linkTarget(url))) {
+			file.skipped++
+			continue
+		}
+		file.total++
+		if !md.Options.sampling.sampled(url) {
+			file.skipped++
+			continue
+		}
+		line, column := linePosition(content, loc.offset)
+		file.links = append(file.links, extractedLink{url: url, line: line, column: column, context: context, severity: severity, image: loc.image})
 	}
+	file.results = make([]MdLink, len(file.links))
+	return file
 }
 
 // Reads files from *.zip archive and filters *.md. At the end deletes folder with downloaded archive
-func checkMdFiles(md *MdReport, Mu *sync.Mutex, out *os.File) {
+func checkMdFiles(md *MdReport, Mu *sync.Mutex, outputs []*ReportOutput) {
 	//defer os.RemoveAll(*md.ZipPath)
-	reader, err := zip.OpenReader(filepath.Join(*md.ZipPath, *md.ZipName))
-	if err != nil {
-		*md.State = ("[ERR] Couldn't open archive " + *md.ZipName + ".\n\t" + err.Error())
+	start := time.Now()
+	defer func() {
+		elapsed := time.Since(start)
+		md.Duration = &elapsed
+	}()
+	// Archive wasn't downloaded
+	if md.State != nil {
+		writeReport(md, Mu, outputs)
 		return
 	}
-	defer reader.Close()
+	// Files might be already listed (e.g. gist files)
+	files := md.Files
+	switch {
+	case files != nil:
+	case md.LocalPath != nil:
+		var err error
+		if files, err = listLocalFiles(*md.LocalPath); err != nil {
+			md.setError(codeReadFailed, "Couldn't read "+*md.LocalPath+" directory.\n\t"+err.Error())
+			writeReport(md, Mu, outputs)
+			return
+		}
+	default:
+		reader, err := zip.OpenReader(filepath.Join(*md.ZipPath, *md.ZipName))
+		if err != nil {
+			md.setError(codeArchiveInvalid, "Couldn't open archive "+*md.ZipName+".\n\t"+err.Error())
+			writeReport(md, Mu, outputs)
+			return
+		}
+		defer reader.Close()
+		files = listArchiveFiles(reader.File, md.Repository.FlatArchive)
+	}
 
-	for _, f := range reader.File {
-		findAndCheckMdFile(md, f)
+	checked, warnings := resolveSymlinks(files, md.Options.Symlinks)
+	md.Warnings = append(warnings, findCaseDuplicates(files)...)
+	// Skipped symlinks are still valid link targets
+	md.Tree = buildArchiveTree(append(append([]SourceFile{}, files...), checked...))
+	md.Sources = map[string]SourceFile{}
+	for _, f := range checked {
+		md.Sources[f.Path] = f
+	}
+	if md.File != nil {
+		var single []SourceFile
+		for _, f := range checked {
+			if f.Path == *md.File {
+				single = append(single, f)
+			}
+		}
+		checked = single
+	}
+	checkSourceFiles(md, checked)
+	// Orphans and versions are meaningful only if all documents of the repository are checked
+	if md.File == nil && md.Files == nil {
+		if md.Options.Orphans {
+			md.reportOrphans()
+		}
+		if md.Options.VersionedDocs {
+			md.checkVersionedDocs()
+		}
 	}
 	if md.MdFileList == nil {
 		s := "[INF] No markdown links were found."
 		md.State = &s
 	} else if *md.AllLinksOK {
 		s := "[INF] No inactive/broken links were found."
+		if n := md.countWarnings(); n > 0 {
+			s = fmt.Sprintf("[INF] No inactive/broken links were found, %d link(s) failed with warning severity.", n)
+		}
 		md.State = &s
 	}
+	writeReport(md, Mu, outputs)
+}
+
+// Writes Markdown/CLI report of a checked repository. Other formats are written at once,
+// after all repositories are checked
+func writeReport(md *MdReport, Mu *sync.Mutex, outputs []*ReportOutput) {
 	Mu.Lock()
 	defer Mu.Unlock()
-	generateReport(md, out)
+	for _, o := range outputs {
+		if isStreamingFormat(o.Format) {
+			generateReport(md, o.File)
+		}
+	}
 }
 
-// Downloads and stores Github repository as zip archive
+// Downloads and stores repository as zip archive
 func downloadGitArchive(md *MdReport) error {
 
 	fullpath := filepath.Join(*md.ZipPath, *md.ZipName)
 	if err := os.MkdirAll(*md.ZipPath, 0755); err != nil {
-		*md.State = ("[ERR] Couldn't create " + *md.ZipPath + " path.\n\t" + err.Error())
+		md.setError(codeWorkDirFailed, "Couldn't create "+*md.ZipPath+" path.\n\t"+err.Error())
 		return err
 	}
 
 	out, err := os.Create(fullpath)
 	if err != nil {
-		*md.State = ("[ERR] Couldn't create " + fullpath + " file.\n\t" + err.Error())
+		md.setError(codeWorkDirFailed, "Couldn't create "+fullpath+" file.\n\t"+err.Error())
 		return err
 	}
 	defer out.Close()
 
-	resp, err := http.Get(*md.ZipUrl)
-
+	resp, err := downloadArchive(*md.ZipUrl, md.Options)
 	if err != nil {
-		*md.State = ("[ERR] Couldn't download " + *md.ZipUrl + " file.\n\t" + err.Error())
+		md.setError(codeDownloadFailed, "Couldn't download "+*md.ZipUrl+" file.\n\t"+err.Error())
 		return err
 	}
 	defer resp.Body.Close()
 
 	if _, err := io.Copy(out, resp.Body); err != nil {
-		*md.State = ("[ERR] Couldn't store downloaded file.\n\t" + err.Error())
+		md.setError(codeWorkDirFailed, "Couldn't store downloaded file.\n\t"+err.Error())
 		return err
 	}
 	return nil
 }
 
-func (l *MdReportList) Append(report MdReport) {
+// Returns repository's URL (or checked local directory)
+func (md *MdReport) RepoUrl() string {
+	if md.Repository.Ref != nil && md.Repository.TreeUrl != nil {
+		return *md.Repository.TreeUrl
+	}
+	if md.Repository.HTMLURL != nil {
+		return *md.Repository.HTMLURL
+	}
+	return filepath.ToSlash(*md.LocalPath)
+}
+
+// Returns URL (or local directory) file paths in a report are relative to
+func (md *MdReport) FilesUrl() string {
+	if md.Repository.WebUrl != nil {
+		return *md.Repository.WebUrl + "/"
+	}
+	return filepath.ToSlash(*md.LocalPath) + "/"
+}
+
+// Returns number of failed links with warning severity
+func (md *MdReport) countWarnings() int {
+	n := 0
+	if md.MdFileList != nil {
+		for _, file := range *md.MdFileList {
+			for _, link := range *file.LinkList {
+				if !*link.Succeed && link.IsWarning() {
+					n++
+				}
+			}
+		}
+	}
+	return n
+}
+
+// Appends message to repository's check state
+func (md *MdReport) addState(s string) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	md.setState(stringValue(md.State) + s)
+}
+
+// Appends warning to repository's report
+func (md *MdReport) addWarning(w ReportWarning) {
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	md.Warnings = append(md.Warnings, w)
+}
+
+// Stores repository's check state (error or information message)
+func (md *MdReport) setState(s string) {
+	md.State = &s
+}
+
+func (l *MdReportList) Append(report *MdReport) {
 	l.Mu.Lock()
 	defer l.Mu.Unlock()
-	l.Reports = append(l.Reports, &report)
+	l.Reports = append(l.Reports, report)
+}
+
+// Sends GET request to GitHub, authorized with token if one is specified
+func githubGet(url, token string) (*http.Response, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" && isGithubHost(request.URL.Hostname()) {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	return http.DefaultClient.Do(request)
+}
+
+// Returns true if GitHub token may be sent to host: GitHub's API, web and download hosts
+func isGithubHost(host string) bool {
+	switch strings.ToLower(host) {
+	case "api.github.com", "github.com", "www.github.com", "codeload.github.com", "raw.githubusercontent.com":
+		return true
+	}
+	return false
+}
+
+// Returns login of token's user, empty if token isn't valid
+func githubLogin(token string) string {
+	var user struct {
+		Login string `json:"login"`
+	}
+	resp, err := githubGet("https://api.github.com/user", token)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	json.NewDecoder(resp.Body).Decode(&user)
+	return user.Login
+}
+
+// Returns true if GitHub account is an organization
+func isGithubOrg(account string, opts *Options) bool {
+	var user struct {
+		Type string `json:"type"`
+	}
+	resp, err := githubGet("https://api.github.com/users/"+account, opts.githubToken())
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(&user) == nil && user.Type == "Organization"
 }
 
-// Returns public/not-forked/not-archived/not-empty repository list
-func GetPublicRepos(account, repo string) []*Repository {
+// Returns public/not-empty repository list of a user or an organization (forks, archived and disabled
+// repositories are included only if options allow)
+func GetPublicRepos(account, repo string, opts *Options) ([]*Repository, error) {
 	var resp *http.Response
 	var err error
-	var allRepos, outRepos []*Repository
+	var outRepos []*Repository
 	var singleRepo *Repository
 
 	switch repo {
 	case "":
-		resp, err = http.Get("https://api.github.com/users/" + account + "/repos?type=owner&per_page=100&type=public")
-		if err != nil {
-			log.Fatalln(err)
+		// Token allows listing all repositories with a single GraphQL query per 100 repositories
+		if opts.Token != "" && opts.app == nil {
+			if outRepos, err = getGraphqlRepos(account, opts); err == nil {
+				return outRepos, nil
+			}
+			log.Println("[WRN] Couldn't list repositories using GraphQL API, falling back to REST API: " + err.Error())
 		}
-		defer resp.Body.Close()
-		if err := json.NewDecoder(resp.Body).Decode(&allRepos); err != nil {
-			log.Fatalln(err)
+		list := "https://api.github.com/users/" + account + "/repos?type=owner&per_page=100&type=public"
+		switch {
+		case opts.app != nil:
+			// Installation can read all (public and private) repositories of its account it was granted
+			list = "https://api.github.com/installation/repositories?per_page=100"
+		case opts.Org || isGithubOrg(account, opts):
+			// Token lists private repositories, which it can read, as well
+			visibility := "public"
+			if opts.Token != "" {
+				visibility = "all"
+			}
+			list = "https://api.github.com/orgs/" + account + "/repos?per_page=100&type=" + visibility
+		case opts.Token != "" && strings.EqualFold(githubLogin(opts.Token), account):
+			list = "https://api.github.com/user/repos?affiliation=owner&visibility=all&per_page=100"
 		}
-		// Store only active, not forked and not empty repos
-		for i := range allRepos {
-			if !*allRepos[i].Fork && !*allRepos[i].Disabled && !*allRepos[i].Archived && *allRepos[i].Size > 0 {
-				outRepos = append(outRepos, allRepos[i])
+		// Follow Link header, so accounts with more than 100 repositories are listed completely
+		for list != "" && !opts.enoughRepos(outRepos) {
+			resp, err = githubGet(list, opts.githubToken())
+			if err != nil {
+				return nil, err
+			}
+			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+				resp.Body.Close()
+				return nil, fmt.Errorf("[ERR] Couldn't list %s repositories: %s", account, resp.Status)
 			}
+			page, err := decodeRepoPage(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			// Store only not empty repos
+			for _, r := range page {
+				if *r.Size > 0 && opts.includes(r) {
+					outRepos = append(outRepos, r)
+				}
+			}
+			list = nextPageUrl(resp.Header.Get("Link"))
 		}
 
 	default:
-		resp, err = http.Get("https://api.github.com/repos/" + account + "/" + repo)
+		resp, err = githubGet("https://api.github.com/repos/"+account+"/"+repo, opts.githubToken())
 		if err != nil {
-			log.Fatalln(err)
+			return nil, err
 		}
 		defer resp.Body.Close()
 		if err := json.NewDecoder(resp.Body).Decode(&singleRepo); err != nil {
-			log.Fatalln(err)
+			return nil, err
 		}
 		// Store response to output
 		if resp.StatusCode == 200 {
@@ -336,117 +881,85 @@ func GetPublicRepos(account, repo string) []*Repository {
 		}
 
 	}
-	return outRepos
+	return outRepos, nil
 
 }
 
-// Parses CLI input and starts repository check in parallel (using goroutines)
-// if no specific repo was defined
-func RunCLI() {
+// Decodes page of repository list: an array, or an object with "repositories" array (installation repositories)
+func decodeRepoPage(body io.Reader) ([]*Repository, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	var page []*Repository
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '{' {
+		var installation struct {
+			Repositories []*Repository `json:"repositories"`
+		}
+		err := json.Unmarshal(raw, &installation)
+		return installation.Repositories, err
+	}
+	err := json.Unmarshal(raw, &page)
+	return page, err
+}
+
+// Returns URL of the next page from Link header (<url>; rel="next", <url>; rel="last"), empty on the last page
+func nextPageUrl(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, _ := strings.Cut(link, ";")
+		if strings.Contains(params, `rel="next"`) {
+			return strings.Trim(strings.TrimSpace(target), "<>")
+		}
+	}
+	return ""
+}
+
+// Downloads and checks account's repositories in parallel (using goroutines).
+// Markdown/CLI reports are written to outputs as soon as a repository is checked
+func runCheck(account, repo string, opts *Options, outputs []*ReportOutput) ([]*MdReport, error) {
 	var mdList MdReportList
-	var githubAccount, githubRepo, resultOutput, reportFileName string
-	var output *os.File
 	var wg sync.WaitGroup
 
-	app := &cli.App{
-		Name:                 "gmuv",
-		Usage:                "CLI tool to validate Markdown URLs",
-		EnableBashCompletion: true,
-		Action: func(c *cli.Context) error {
-			return nil
-		},
-		Flags: []cli.Flag{
-			&cli.StringFlag{
-				Name:        "username",
-				Aliases:     []string{"u"},
-				Value:       "",
-				Usage:       "GitHub account name",
-				Destination: &githubAccount,
-				Required:    true,
-			},
-			&cli.StringFlag{
-				Name:        "repository",
-				Aliases:     []string{"r"},
-				Value:       "",
-				Usage:       "GitHub repository name",
-				Destination: &githubRepo,
-			},
-			&cli.StringFlag{
-				Name:        "output",
-				Aliases:     []string{"o"},
-				Value:       "file",
-				Usage:       "Output format: cli or file",
-				Destination: &resultOutput,
-			},
-			&cli.StringFlag{
-				Name:        "filename",
-				Aliases:     []string{"f"},
-				Value:       "REPORT.md",
-				Usage:       "Results filename",
-				Destination: &reportFileName,
-			},
-		},
+	if opts.File != "" {
+		return runFileCheck(opts, outputs)
 	}
-
-	err := app.Run(os.Args)
-	if err != nil {
-		log.Fatal(err)
+	if opts.GitUrl != "" {
+		return runGitUrlCheck(opts, outputs)
 	}
-
-	// Do not continue if no Github account is specified
-	if githubAccount == "" {
-		return
+	if opts.Gists {
+		return runGistCheck(account, opts, outputs)
 	}
-
-	path, err := os.Getwd()
-	if err != nil {
-		log.Fatalln(err)
+	if opts.Pr != 0 {
+		return runPrCheck(account, repo, opts, outputs)
 	}
-	execPath = filepath.Join(path, ".archives")
-
-	switch resultOutput {
-	case "cli":
-		output = os.Stdout
-	case "file":
-		output, err = os.Create(filepath.Join(path, reportFileName))
-		if err != nil {
-			log.Fatalln(err)
-		}
-		defer output.Close()
+	if opts.LocalPath != "" {
+		return runLocalCheck(opts, outputs)
 	}
-
-	repos := GetPublicRepos(githubAccount, githubRepo)
-	reposNumber := len(repos)
-
-	if reposNumber == 0 {
-		output.Write([]byte("[INF] No repositories were found\n"))
-		return
+	repos, err := listRepositories(account, repo, opts)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "[ERR]") {
+			return nil, err
+		}
+		return nil, codedError(codeListFailed, "Couldn't list repositories of "+account+".\n\t"+err.Error())
 	}
-
-	report := make([]*MdReport, reposNumber)
-	mdList.Reports = report
+	mdList.Reports = make([]*MdReport, 0, len(repos))
 
 	// Store and parse public and active repositories
 	for _, repo := range repos {
 		wg.Add(1)
 		go func(r *Repository) {
 			defer wg.Done()
-			var repoUrl string
 			md := new(MdReport)
 			allLinksDefVal := true
 			md.AllLinksOK = &allLinksDefVal
 			md.Repository = r
-			downloadLink := *r.HTMLURL + "/archive/refs/heads/" + *r.DefaultBranch + ".zip"
 			archiveName := *r.Name + ".zip"
-			downloadPath := filepath.Join(execPath, *r.Name)
-			repoUrl = (*r.HTMLURL + "/blob/" + *r.DefaultBranch)
-			md.ZipUrl, md.ZipName, md.ZipPath, md.Repository.WebUrl = &downloadLink, &archiveName, &downloadPath, &repoUrl
-			err := downloadGitArchive(md)
-			if err != nil {
-				state := (*md.State + " [ERR] Couldn't download " + ": \n\t" + err.Error())
-				md.State = &state
-			}
-			mdList.Append(*md)
+			downloadPath := filepath.Join(opts.WorkDir, *r.Name)
+			md.ZipUrl, md.ZipName, md.ZipPath = r.ArchiveUrl, &archiveName, &downloadPath
+			md.Options = opts
+			// Failure is stored in report's state
+			downloadGitArchive(md)
+			mdList.Append(md)
 		}(repo)
 	}
 	wg.Wait()
@@ -458,13 +971,783 @@ func RunCLI() {
 			wg.Add(1)
 			go func(m *MdReport) {
 				defer wg.Done()
-				checkMdFiles(m, mux, output)
+				checkMdFiles(m, mux, outputs)
 			}(md)
 		}
 
 	}
 	wg.Wait()
 
+	if opts.IncludeWikis {
+		var wikis MdReportList
+		for _, r := range repos {
+			if r.HasWiki != nil && *r.HasWiki {
+				wg.Add(1)
+				go func(r *Repository) {
+					defer wg.Done()
+					if md := checkWiki(r, opts, outputs, mux); md != nil {
+						wikis.Append(md)
+					}
+				}(r)
+			}
+		}
+		wg.Wait()
+		mdList.Reports = append(mdList.Reports, wikis.Reports...)
+	}
+	return mdList.Reports, nil
+}
+
+// Checks links in local directory's markdown files without downloading anything
+func runLocalCheck(opts *Options, outputs []*ReportOutput) ([]*MdReport, error) {
+	var mu sync.Mutex
+	root, err := filepath.Abs(opts.LocalPath)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return nil, codedError(codeInvalidPath, opts.LocalPath+" is not a directory")
+	}
+	name := filepath.Base(root)
+	allLinksDefVal := true
+	md := &MdReport{Repository: &Repository{Name: &name}, LocalPath: &root, AllLinksOK: &allLinksDefVal, Options: opts}
+	// CI checkout is reported as its repository
+	if opts.ci != nil {
+		md.Repository = opts.ci.repository()
+		if md.Repository.Name == nil {
+			md.Repository.Name = &name
+		}
+	}
+	checkMdFiles(md, &mu, outputs)
+	return []*MdReport{md}, nil
+}
+
+// Checks account's repositories and writes results in every requested format
+// (to the console or to report files), so links are checked only once
+func checkAndReport(account, repo, filename string, opts *Options) ([]*MdReport, error) {
+	outputs, err := parseOutputs(opts.Outputs, filename)
+	if err != nil {
+		return nil, err
+	}
+	defer closeOutputs(outputs)
+	if err := openOutputs(outputs); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	reports, err := runCheck(account, repo, opts, outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(reports) == 0 {
+		for _, o := range outputs {
+			if isStreamingFormat(o.Format) {
+				o.File.Write([]byte("[INF] No repositories were found\n"))
+			}
+		}
+	}
+	if err := writeDocuments(outputs, reports, time.Since(start)); err != nil {
+		return reports, err
+	}
+	if err := signOutputs(outputs, opts.SignKey); err != nil {
+		return reports, err
+	}
+	if err := applyFixes(reports, opts); err != nil {
+		return reports, err
+	}
+	if err := openFixPullRequests(reports, opts); err != nil {
+		return reports, err
+	}
+	return reports, exportProject(reports, opts)
+}
+
+// Parses CLI input and starts repository check in parallel (using goroutines)
+// if no specific repo was defined
+func RunCLI() {
+	var opts Options
+	var githubAccount, githubRepo, reportFileName string
+	var linkPatterns patternList
+
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:        "username",
+			Aliases:     []string{"u"},
+			Value:       "",
+			Usage:       "GitHub account (Bitbucket workspace, Azure DevOps <organization>/<project>) name",
+			EnvVars:     []string{"GMUV_USERNAME"},
+			Destination: &githubAccount,
+		},
+		&cli.StringFlag{
+			Name:        "repository",
+			Aliases:     []string{"r"},
+			Value:       "",
+			Usage:       "GitHub repository name",
+			EnvVars:     []string{"GMUV_REPOSITORY"},
+			Destination: &githubRepo,
+		},
+		&cli.BoolFlag{
+			Name:        "org",
+			Usage:       "Account is a GitHub organization (detected automatically if not set)",
+			EnvVars:     []string{"GMUV_ORG"},
+			Destination: &opts.Org,
+		},
+		&cli.StringFlag{
+			Name:        "ref",
+			Usage:       "Branch, tag or commit SHA to check (default: repository's default branch)",
+			EnvVars:     []string{"GMUV_REF"},
+			Destination: &opts.Ref,
+		},
+		&cli.IntFlag{
+			Name:        "max-repos",
+			Value:       defaultMaxRepos,
+			Usage:       "Upper limit of checked repositories per run, 0 means unlimited",
+			EnvVars:     []string{"GMUV_MAX_REPOS"},
+			Destination: &opts.MaxRepos,
+		},
+		&cli.StringSliceFlag{
+			Name:    "topic",
+			Usage:   "Check only repositories with any of these topics (e.g. docs)",
+			EnvVars: []string{"GMUV_TOPIC"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "repo-include",
+			Usage:   "Check only repositories, which names match any of these glob patterns (e.g. 'docs-*')",
+			EnvVars: []string{"GMUV_REPO_INCLUDE"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "repo-exclude",
+			Usage:   "Skip repositories, which names match any of these glob patterns (e.g. 'archive-*,playground')",
+			EnvVars: []string{"GMUV_REPO_EXCLUDE"},
+		},
+		&cli.BoolFlag{
+			Name:        "include-forks",
+			Usage:       "Check forked repositories",
+			EnvVars:     []string{"GMUV_INCLUDE_FORKS"},
+			Destination: &opts.IncludeForks,
+		},
+		&cli.BoolFlag{
+			Name:        "include-archived",
+			Usage:       "Check archived repositories",
+			EnvVars:     []string{"GMUV_INCLUDE_ARCHIVED"},
+			Destination: &opts.IncludeArchived,
+		},
+		&cli.BoolFlag{
+			Name:        "include-disabled",
+			Usage:       "Check disabled repositories",
+			EnvVars:     []string{"GMUV_INCLUDE_DISABLED"},
+			Destination: &opts.IncludeDisabled,
+		},
+		&cli.IntFlag{
+			Name:        "pr",
+			Usage:       "Check only markdown files changed by the pull request (GitHub repository must be specified)",
+			EnvVars:     []string{"GMUV_PR"},
+			Destination: &opts.Pr,
+		},
+		&cli.BoolFlag{
+			Name:        "gists",
+			Usage:       "Check markdown files of user's public gists (reported per gist) instead of repositories",
+			EnvVars:     []string{"GMUV_GISTS"},
+			Destination: &opts.Gists,
+		},
+		&cli.BoolFlag{
+			Name:        "include-wikis",
+			Usage:       "Also check markdown pages of repositories' wikis (<repo>.wiki.git)",
+			EnvVars:     []string{"GMUV_INCLUDE_WIKIS"},
+			Destination: &opts.IncludeWikis,
+		},
+		&cli.StringFlag{
+			Name:        "provider",
+			Value:       "github",
+			Usage:       "Repository hosting provider: github, bitbucket, gitea, forgejo or azure",
+			EnvVars:     []string{"GMUV_PROVIDER"},
+			Destination: &opts.Provider,
+		},
+		&cli.StringFlag{
+			Name:        "provider-url",
+			Usage:       "Self-hosted provider's server URL (e.g. https://gitea.example.com)",
+			EnvVars:     []string{"GMUV_PROVIDER_URL"},
+			Destination: &opts.ProviderUrl,
+		},
+		&cli.StringFlag{
+			Name:        "token",
+			Usage:       "GitHub token, which authorizes API calls and archive downloads (raises rate limits, private repositories are checked as well)",
+			EnvVars:     []string{"GMUV_TOKEN", "GITHUB_TOKEN"},
+			Destination: &opts.Token,
+		},
+		&cli.StringFlag{
+			Name:    "app-id",
+			Usage:   "GitHub App ID, which authenticates as App's installation instead of --token",
+			EnvVars: []string{"GMUV_APP_ID"},
+		},
+		&cli.StringFlag{
+			Name:    "app-key",
+			Usage:   "GitHub App private key (PEM)",
+			EnvVars: []string{"GMUV_APP_KEY"},
+		},
+		&cli.StringFlag{
+			Name:    "app-installation",
+			Usage:   "GitHub App installation ID (default: installation on --username account)",
+			EnvVars: []string{"GMUV_APP_INSTALLATION"},
+		},
+		&cli.StringFlag{
+			Name:    "gitea-token",
+			Usage:   "Gitea/Forgejo access token, which is sent to --provider-url only",
+			EnvVars: []string{"GMUV_GITEA_TOKEN", "GITEA_TOKEN"},
+		},
+		&cli.StringFlag{
+			Name:    "bitbucket-token",
+			Usage:   "Bitbucket Cloud access token (repository, project or workspace token), which is sent to Bitbucket only",
+			EnvVars: []string{"GMUV_BITBUCKET_TOKEN", "BITBUCKET_TOKEN"},
+		},
+		&cli.StringFlag{
+			Name:    "azure-pat",
+			Usage:   "Azure DevOps personal access token (Code: Read scope)",
+			EnvVars: []string{"GMUV_AZURE_PAT", "AZURE_DEVOPS_EXT_PAT"},
+		},
+		&cli.StringFlag{
+			Name:        "path",
+			Aliases:     []string{"p"},
+			Usage:       "Local directory to check instead of GitHub repositories (e.g. uncommitted docs), root directory for --file",
+			EnvVars:     []string{"GMUV_PATH"},
+			Destination: &opts.LocalPath,
+		},
+		&cli.StringSliceFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Value:   cli.NewStringSlice("file"),
+			Usage:   "Output formats: cli, file, md, json, sarif, junit, html, links (CSV classification of all links), graph (JSON) or dot (Graphviz) graph of links between files and domains, github or azure (CI annotations). Several formats can be combined (e.g. md,json=report.json)",
+			EnvVars: []string{"GMUV_OUTPUT"},
+		},
+		&cli.StringFlag{
+			Name:        "git-url",
+			Usage:       "Any Git repository to clone and check (e.g. https://git.example.com/team/docs.git)",
+			EnvVars:     []string{"GMUV_GIT_URL"},
+			Destination: &opts.GitUrl,
+		},
+		&cli.StringFlag{
+			Name:        "web-base",
+			Usage:       "URL which relative links of --git-url repository are resolved against (by default they're validated against cloned tree)",
+			EnvVars:     []string{"GMUV_WEB_BASE"},
+			Destination: &opts.WebBase,
+		},
+		&cli.StringFlag{
+			Name:        "file",
+			Usage:       "Single markdown file to check",
+			EnvVars:     []string{"GMUV_FILE"},
+			Destination: &opts.File,
+		},
+		&cli.StringFlag{
+			Name:        "base-url",
+			Usage:       "URL which relative links of --file are resolved against (by default they're validated against local root directory)",
+			EnvVars:     []string{"GMUV_BASE_URL"},
+			Destination: &opts.BaseUrl,
+		},
+		&cli.StringFlag{
+			Name:        "filename",
+			Aliases:     []string{"f"},
+			Value:       "REPORT.md",
+			Usage:       "Results filename",
+			EnvVars:     []string{"GMUV_FILENAME"},
+			Destination: &reportFileName,
+		},
+		&cli.BoolFlag{
+			Name:        "github-compat",
+			Usage:       "Resolve relative links exactly the way GitHub renders them",
+			EnvVars:     []string{"GMUV_GITHUB_COMPAT"},
+			Destination: &opts.GithubCompat,
+		},
+		&cli.BoolFlag{
+			Name:        "skip-generated",
+			Usage:       "Skip auto-generated markdown files (e.g. 'Code generated by ... DO NOT EDIT' header) instead of marking them",
+			EnvVars:     []string{"GMUV_SKIP_GENERATED"},
+			Destination: &opts.SkipGenerated,
+		},
+		&cli.StringSliceFlag{
+			Name:    "generated-paths",
+			Usage:   "Path patterns of generator output (e.g. docs/api/, *.gen.md), files there are treated as auto-generated",
+			EnvVars: []string{"GMUV_GENERATED_PATHS"},
+		},
+		&cli.StringFlag{
+			Name:        "method",
+			Value:       methodHead,
+			Usage:       "Request method of external links: head (GET if the server responds 405/403) or get",
+			EnvVars:     []string{"GMUV_METHOD"},
+			Destination: &opts.Method,
+		},
+		&cli.DurationFlag{
+			Name:        "timeout",
+			Value:       30 * time.Second,
+			Usage:       "Deadline of a link check (connection, TLS handshake, redirects and response headers), 0 disables it",
+			EnvVars:     []string{"GMUV_TIMEOUT"},
+			Destination: &opts.Timeout,
+		},
+		&cli.IntFlag{
+			Name:        "max-attempts",
+			Value:       defaultMaxAttempts,
+			Usage:       "Attempts of a link check, which fails transiently (429, 5xx or timeout), 1 disables retries",
+			EnvVars:     []string{"GMUV_MAX_ATTEMPTS"},
+			Destination: &opts.MaxAttempts,
+		},
+		&cli.DurationFlag{
+			Name:        "retry-backoff",
+			Value:       defaultRetryBackoff,
+			Usage:       "Pause before the first retry, doubled by every next one (up to 30s, with random jitter)",
+			EnvVars:     []string{"GMUV_RETRY_BACKOFF"},
+			Destination: &opts.RetryBackoff,
+		},
+		&cli.StringSliceFlag{
+			Name:    "method-rules",
+			Usage:   "Request methods per domain, which include subdomains (e.g. example.com=get)",
+			EnvVars: []string{"GMUV_METHOD_RULES"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "host-limits",
+			Usage:   "Concurrent requests and optional requests per second per host, which include subdomains, \"*\" limits other hosts (e.g. github.com=2:1)",
+			EnvVars: []string{"GMUV_HOST_LIMITS"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "context-rules",
+			Usage:   "Severity of failed links per context (prose, badge, table, footnote, heading): error, warning or ignore (e.g. badge=warning,footnote=ignore)",
+			EnvVars: []string{"GMUV_CONTEXT_RULES"},
+		},
+		&cli.StringFlag{
+			Name:        "slug",
+			Value:       "github",
+			Usage:       "Slug algorithm of the rendering platform, which fragment links are validated with: github, gitlab, kramdown or goldmark",
+			EnvVars:     []string{"GMUV_SLUG"},
+			Destination: &opts.Slug,
+		},
+		&cli.IntFlag{
+			Name:        "extract-workers",
+			Usage:       "Number of workers, which parse markdown files of a repository (default: number of CPUs)",
+			EnvVars:     []string{"GMUV_EXTRACT_WORKERS"},
+			Destination: &opts.ExtractWorkers,
+		},
+		&cli.IntFlag{
+			Name:        "check-workers",
+			Value:       defaultCheckWorkers,
+			Usage:       "Number of workers, which check links of a repository",
+			EnvVars:     []string{"GMUV_CHECK_WORKERS"},
+			Destination: &opts.CheckWorkers,
+		},
+		&cli.IntFlag{
+			Name:        "concurrency",
+			Value:       defaultConcurrency,
+			Usage:       "Maximum of concurrent link checks of all files and repositories (--check-workers defaults to it, when it's set)",
+			EnvVars:     []string{"GMUV_CONCURRENCY"},
+			Destination: &opts.Concurrency,
+		},
+		&cli.StringFlag{
+			Name:    "cache",
+			Usage:   "Directory or shared storage URL (see 'serve --storage'), which stores verified links between runs (e.g. ~/.cache/gmuv)",
+			EnvVars: []string{"GMUV_CACHE"},
+		},
+		&cli.DurationFlag{
+			Name:        "cache-ttl",
+			Value:       defaultCacheTtl,
+			Usage:       "Time, which links verified by earlier runs aren't rechecked for",
+			EnvVars:     []string{"GMUV_CACHE_TTL"},
+			Destination: &opts.CacheTtl,
+		},
+		&cli.DurationFlag{
+			Name:        "dns-timeout",
+			Value:       defaultDnsTimeout,
+			Usage:       "Deadline of a host name lookup, results of lookups are shared by all checks (0 - none)",
+			EnvVars:     []string{"GMUV_DNS_TIMEOUT"},
+			Destination: &opts.DnsTimeout,
+		},
+		&cli.StringFlag{
+			Name:    "max-memory",
+			Usage:   "Approximate limit of markdown contents held in memory at once (e.g. 256M), peak RSS is roughly this value plus extracted links (default: unlimited)",
+			EnvVars: []string{"GMUV_MAX_MEMORY"},
+		},
+		&cli.StringFlag{
+			Name:        "project",
+			Usage:       "Export broken links to GitHub Project (v2) as draft issues: <owner>/<number> (token needs project scope)",
+			EnvVars:     []string{"GMUV_PROJECT"},
+			Destination: &opts.Project,
+		},
+		&cli.StringFlag{
+			Name:        "project-items",
+			Value:       "link",
+			Usage:       "Project item per broken link (link) or per repository (repo)",
+			EnvVars:     []string{"GMUV_PROJECT_ITEMS"},
+			Destination: &opts.ProjectItems,
+		},
+		&cli.StringFlag{
+			Name:        "project-field",
+			Value:       "Status",
+			Usage:       "Project's single select field, which is set to Todo for broken links and Done for fixed ones",
+			EnvVars:     []string{"GMUV_PROJECT_FIELD"},
+			Destination: &opts.ProjectField,
+		},
+		&cli.BoolFlag{
+			Name:        "check-bare-urls",
+			Usage:       "Check autolinks (<https://...>) and plain URLs pasted into text too",
+			EnvVars:     []string{"GMUV_CHECK_BARE_URLS"},
+			Destination: &opts.CheckBareUrls,
+		},
+		&cli.IntFlag{
+			Name:        "max-errors",
+			Value:       -1,
+			Usage:       "Exit with code 1 if there are more broken links (and failed repository checks), -1 means never",
+			EnvVars:     []string{"GMUV_MAX_ERRORS"},
+			Destination: &opts.MaxErrors,
+		},
+		&cli.IntFlag{
+			Name:        "max-warnings",
+			Value:       -1,
+			Usage:       "Exit with code 1 if there are more warnings, -1 means never",
+			EnvVars:     []string{"GMUV_MAX_WARNINGS"},
+			Destination: &opts.MaxWarnings,
+		},
+		&cli.BoolFlag{
+			Name:        "strict-placeholders",
+			Usage:       "Report placeholder links (example.com, your-domain.here, <ORG>, {{ var }}) as broken instead of skipping them",
+			EnvVars:     []string{"GMUV_STRICT_PLACEHOLDERS"},
+			Destination: &opts.StrictPlaceholders,
+		},
+		&cli.BoolFlag{
+			Name:        "orphans",
+			Usage:       "Warn about markdown files, which no other document of the repository links to",
+			EnvVars:     []string{"GMUV_ORPHANS"},
+			Destination: &opts.Orphans,
+		},
+		&cli.StringSliceFlag{
+			Name:    "entry-point",
+			Value:   cli.NewStringSlice(defaultEntryPoints...),
+			Usage:   "Markdown files (name or path glob, e.g. docs/index.md), which aren't orphaned without inbound links",
+			EnvVars: []string{"GMUV_ENTRY_POINT"},
+		},
+		&cli.BoolFlag{
+			Name:        "history-hints",
+			Usage:       "Look up the commit range, in which a missing repository file was removed or renamed, via GitHub API (one request per missing file)",
+			EnvVars:     []string{"GMUV_HISTORY_HINTS"},
+			Destination: &opts.HistoryHints,
+		},
+		&cli.BoolFlag{
+			Name:        "verify-mailto",
+			Usage:       "Validate syntax of mailto: addresses and look up mail exchangers (MX) of their domains instead of skipping them",
+			EnvVars:     []string{"GMUV_VERIFY_MAILTO"},
+			Destination: &opts.VerifyMailto,
+		},
+		&cli.BoolFlag{
+			Name:        "fix",
+			Usage:       "Rewrite broken links of the local directory (--path) to their suggested targets, e.g. new locations of moved files",
+			EnvVars:     []string{"GMUV_FIX"},
+			Destination: &opts.Fix,
+		},
+		&cli.BoolFlag{
+			Name:        "fix-pr",
+			Usage:       "Open a pull request per checked GitHub repository, which rewrites broken links to their suggested targets (needs a token with contents and pull requests write access)",
+			EnvVars:     []string{"GMUV_FIX_PR"},
+			Destination: &opts.FixPr,
+		},
+		&cli.BoolFlag{
+			Name:        "preview",
+			Usage:       "Print fixes as a unified diff and write fixed files to a temporary directory instead of changing the working tree",
+			EnvVars:     []string{"GMUV_PREVIEW"},
+			Destination: &opts.Preview,
+		},
+		&cli.BoolFlag{
+			Name:        "versioned-docs",
+			Usage:       "Warn about links of versioned docs (docs/v1, docs/v2), which cross into another version, and documents missing in the latest version",
+			EnvVars:     []string{"GMUV_VERSIONED_DOCS"},
+			Destination: &opts.VersionedDocs,
+		},
+		&cli.BoolFlag{
+			Name:        "check-code-links",
+			Usage:       "Check markdown links in fenced and indented code blocks and inline code too (they are examples by default)",
+			EnvVars:     []string{"GMUV_CHECK_CODE_LINKS"},
+			Destination: &opts.CheckCodeLinks,
+		},
+		&cli.BoolFlag{
+			Name:        "check-front-matter",
+			Usage:       "Check URL-valued fields of YAML/TOML front matter (front matter is skipped by default)",
+			EnvVars:     []string{"GMUV_CHECK_FRONT_MATTER"},
+			Destination: &opts.CheckFrontMatter,
+		},
+		&cli.BoolFlag{
+			Name:        "check-html",
+			Usage:       "Check href and src attributes of .html/.htm files too",
+			EnvVars:     []string{"GMUV_CHECK_HTML"},
+			Destination: &opts.CheckHtml,
+		},
+		&cli.StringSliceFlag{
+			Name:    "extensions",
+			Usage:   "Extensions of checked files, which replace supported documents (md, adoc, asciidoc, rst, ipynb), e.g. md,markdown,mdx,txt. Files without a dedicated parser are checked as markdown",
+			EnvVars: []string{"GMUV_EXTENSIONS"},
+		},
+		&cli.GenericFlag{
+			Name:    "link-pattern",
+			Value:   &linkPatterns,
+			Usage:   "Regular expression of additional links (e.g. shortcodes), the first capturing group is the link (the whole match, if there is none). Can be repeated",
+			EnvVars: []string{"GMUV_LINK_PATTERN"},
+		},
+		&cli.BoolFlag{
+			Name:        "check-image-types",
+			Usage:       "Fail image links, which respond with a non-image Content-Type (e.g. an HTML error page with 200 status)",
+			EnvVars:     []string{"GMUV_CHECK_IMAGE_TYPES"},
+			Destination: &opts.CheckImageTypes,
+		},
+		&cli.StringSliceFlag{
+			Name:    "sample",
+			Usage:   "Check only a sample of links for a quick health estimate: rate (e.g. 10%) and/or per-domain rates (e.g. example.com=50%)",
+			EnvVars: []string{"GMUV_SAMPLE"},
+		},
+		&cli.DurationFlag{
+			Name:        "download-stagger",
+			Value:       250 * time.Millisecond,
+			Usage:       "Minimal interval between repository archive download starts (random jitter up to the interval is added)",
+			EnvVars:     []string{"GMUV_DOWNLOAD_STAGGER"},
+			Destination: &opts.DownloadStagger,
+		},
+		&cli.BoolFlag{
+			Name:        "http3",
+			Usage:       "Check links over HTTP/3 (QUIC) first, falling back to HTTP/1.1 or HTTP/2 if a server can't be reached",
+			EnvVars:     []string{"GMUV_HTTP3"},
+			Destination: &opts.Http3,
+		},
+		&cli.IntFlag{
+			Name:        "max-redirects",
+			Value:       defaultMaxRedirects,
+			Usage:       "Maximum of redirects followed by a link check, 0 reports redirects without following them",
+			EnvVars:     []string{"GMUV_MAX_REDIRECTS"},
+			Destination: &opts.MaxRedirects,
+		},
+		&cli.BoolFlag{
+			Name:        "warn-permanent-redirects",
+			Usage:       "Report links, which are redirected permanently (301/308), as warnings with the final URL (applied by --fix)",
+			EnvVars:     []string{"GMUV_WARN_PERMANENT_REDIRECTS"},
+			Destination: &opts.WarnRedirects,
+		},
+		&cli.BoolFlag{
+			Name:        "insecure",
+			Usage:       "Don't verify TLS certificates of checked links (e.g. self-signed certificates of internal hosts)",
+			EnvVars:     []string{"GMUV_INSECURE"},
+			Destination: &opts.Insecure,
+		},
+		&cli.StringFlag{
+			Name:        "ca-cert",
+			Usage:       "PEM file of CA certificates, which are trusted in addition to system CAs (e.g. corporate CA)",
+			EnvVars:     []string{"GMUV_CA_CERT"},
+			Destination: &opts.CaCert,
+		},
+		&cli.StringFlag{
+			Name:        "min-tls-version",
+			Usage:       "Minimal TLS version of checked links: 1.0, 1.1, 1.2 or 1.3",
+			EnvVars:     []string{"GMUV_MIN_TLS_VERSION"},
+			Destination: &opts.MinTlsVersion,
+		},
+		&cli.StringFlag{
+			Name:        "symlinks",
+			Value:       "skip",
+			Usage:       "Symlinked markdown files policy: follow (check target's content under symlink's path) or skip",
+			EnvVars:     []string{"GMUV_SYMLINKS"},
+			Destination: &opts.Symlinks,
+		},
+		&cli.StringFlag{
+			Name:        "path-style",
+			Value:       "posix",
+			Usage:       "File paths style in a report: posix, windows or native",
+			EnvVars:     []string{"GMUV_PATH_STYLE"},
+			Destination: &opts.PathStyle,
+		},
+		&cli.StringSliceFlag{
+			Name:    "schemes",
+			Value:   cli.NewStringSlice(defaultSchemes...),
+			Usage:   "URL schemes of links, which are checked (only http and https can be requested)",
+			EnvVars: []string{"GMUV_SCHEMES"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "skip-schemes",
+			Value:   cli.NewStringSlice(defaultSkipSchemes...),
+			Usage:   "URL schemes of links, which can't be validated and are counted as skipped. Links with other schemes are reported",
+			EnvVars: []string{"GMUV_SKIP_SCHEMES"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "egress-schemes",
+			Usage:   "URL schemes allowed for link checks (default: http,https when any egress restriction is set)",
+			EnvVars: []string{"GMUV_EGRESS_SCHEMES"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "egress-deny",
+			Usage:   "Networks (CIDRs or addresses) link checks must not connect to, 'private' denies loopback, private and link-local (metadata) networks",
+			EnvVars: []string{"GMUV_EGRESS_DENY"},
+		},
+		&cli.StringSliceFlag{
+			Name:    "egress-ports",
+			Usage:   "Destination ports allowed for link checks (default: any)",
+			EnvVars: []string{"GMUV_EGRESS_PORTS"},
+		},
+		&cli.StringFlag{
+			Name:        "record",
+			Usage:       "Record HTTP interactions (API, archives and link checks) to a directory",
+			EnvVars:     []string{"GMUV_RECORD"},
+			Destination: &opts.Record,
+		},
+		&cli.StringFlag{
+			Name:        "replay",
+			Usage:       "Replay HTTP interactions recorded by --record instead of sending requests",
+			EnvVars:     []string{"GMUV_REPLAY"},
+			Destination: &opts.Replay,
+		},
+		&cli.StringFlag{
+			Name:    "sign-key",
+			Usage:   "Ed25519 private key (PEM), which signs JSON reports (detached <report>.sig file)",
+			EnvVars: []string{"GMUV_SIGN_KEY"},
+		},
+		&cli.StringFlag{
+			Name:    "config",
+			Usage:   "JSON file with flag values (e.g. mounted ConfigMap), command line and environment take precedence",
+			EnvVars: []string{"GMUV_CONFIG"},
+		},
+		&cli.StringFlag{
+			Name:    "profile",
+			Usage:   "Run profile from the config file (\"run-profiles\"), which values override top-level ones",
+			EnvVars: []string{"GMUV_PROFILE"},
+		},
+	}
+
+	app := &cli.App{
+		Name:                 "gmuv",
+		Usage:                "CLI tool to validate Markdown URLs",
+		EnableBashCompletion: true,
+		Flags:                flags,
+		Before: func(c *cli.Context) error {
+			path, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			execPath = filepath.Join(path, ".archives")
+			opts.WorkDir = execPath
+			if err := applyConfigFile(c, c.String("config"), c.String("profile"), flags); err != nil {
+				return err
+			}
+			opts.Outputs = c.StringSlice("output")
+			opts.GeneratedPaths = c.StringSlice("generated-paths")
+			opts.Topics = c.StringSlice("topic")
+			opts.RepoInclude, opts.RepoExclude = c.StringSlice("repo-include"), c.StringSlice("repo-exclude")
+			if err := validateRepoPatterns(append(opts.RepoInclude, opts.RepoExclude...)); err != nil {
+				return err
+			}
+			// Every provider has its own token, so GitHub token never leaks to other servers
+			switch opts.Provider {
+			case "azure":
+				opts.ProviderToken = c.String("azure-pat")
+			case "gitea":
+				opts.ProviderToken = c.String("gitea-token")
+			case "bitbucket":
+				opts.ProviderToken = c.String("bitbucket-token")
+			}
+			if id := c.String("app-id"); id != "" {
+				if opts.app, err = loadGithubApp(id, c.String("app-key"), c.String("app-installation"), githubAccount); err != nil {
+					return err
+				}
+			}
+			if opts.MethodRules, err = parseMethodRules(opts.Method, c.StringSlice("method-rules")); err != nil {
+				return err
+			}
+			if opts.ContextRules, err = parseContextRules(c.StringSlice("context-rules")); err != nil {
+				return err
+			}
+			if size := c.String("max-memory"); size != "" {
+				limit, err := parseByteSize(size)
+				if err != nil {
+					return err
+				}
+				opts.memory = newMemoryBudget(limit)
+			}
+			if opts.Slug != "" {
+				if _, err := slugAlgorithm(opts.Slug); err != nil {
+					return err
+				}
+			}
+			// Explicit concurrency is used by a single repository too
+			if c.IsSet("concurrency") && !c.IsSet("check-workers") {
+				opts.CheckWorkers = opts.Concurrency
+			}
+			opts.checks = newCheckPool(opts.Concurrency)
+			if opts.cache, err = newLinkStore(c.String("cache"), opts.CacheTtl); err != nil {
+				return err
+			}
+			opts.results = newResultCache(opts.cache)
+			opts.dns = newDnsCache(opts.DnsTimeout)
+			hostLimits, err := parseHostLimits(c.StringSlice("host-limits"))
+			if err != nil {
+				return err
+			}
+			opts.throttle = newAdaptiveLimiter(opts.CheckWorkers, hostLimits)
+			opts.downloads = newDownloadGate(opts.DownloadStagger)
+			opts.visibility = &repoVisibility{private: map[string]bool{}}
+			opts.clients = &webClients{}
+			if opts.ProjectItems != "link" && opts.ProjectItems != "repo" {
+				return cli.Exit("[ERR] Unknown project items mode "+opts.ProjectItems, 1)
+			}
+			if opts.sampling, err = parseSampling(c.StringSlice("sample")); err != nil {
+				return err
+			}
+			opts.Sample = c.StringSlice("sample")
+			// Links left out of the sample would make their targets look orphaned
+			if opts.Orphans && opts.sampling != nil {
+				return cli.Exit("[ERR] --orphans can't be used with --sample", 1)
+			}
+			opts.EntryPoints = c.StringSlice("entry-point")
+			opts.Extensions = parseExtensions(c.StringSlice("extensions"))
+			if opts.LinkPatterns, err = parseLinkPatterns(linkPatterns); err != nil {
+				return err
+			}
+			if opts.Schemes, opts.SkipSchemes, err = parseSchemes(c.StringSlice("schemes"), c.StringSlice("skip-schemes")); err != nil {
+				return err
+			}
+			if opts.recorder, err = newHttpRecorder(opts.Record, opts.Replay); err != nil {
+				return err
+			}
+			if opts.recorder != nil {
+				http.DefaultClient.Transport = opts.recorder.wrap(http.DefaultClient.Transport)
+			}
+			if opts.Symlinks != "follow" && opts.Symlinks != "skip" {
+				return cli.Exit("[ERR] Unknown symlinks policy "+opts.Symlinks, 1)
+			}
+			if key := c.String("sign-key"); key != "" {
+				if opts.SignKey, err = loadSigningKey(key); err != nil {
+					return err
+				}
+			}
+			if opts.tls, err = newTlsConfig(opts.Insecure, opts.CaCert, opts.MinTlsVersion); err != nil {
+				return err
+			}
+			opts.Egress, err = newEgressPolicy(c.StringSlice("egress-schemes"), c.StringSlice("egress-deny"), c.StringSlice("egress-ports"))
+			return err
+		},
+		Action: func(c *cli.Context) error {
+			// Do not continue if no Github account (or local directory) is specified
+			if githubAccount == "" && opts.LocalPath == "" && opts.File == "" && opts.GitUrl == "" {
+				return cli.Exit("[ERR] GitHub account name is not specified", 1)
+			}
+			start := time.Now()
+			reports, err := checkAndReport(githubAccount, githubRepo, reportFileName, &opts)
+			if err != nil {
+				return err
+			}
+			return exitSummary(reports, time.Since(start), &opts)
+		},
+		Commands: append([]*cli.Command{
+			checkCommand(&reportFileName, &opts),
+			trendsCommand(),
+			keygenCommand(),
+			verifyCommand(),
+			codesCommand(),
+			explainCommand(),
+			ciCommand(&reportFileName, &opts),
+			doctorCommand(&githubAccount, &opts),
+		}, builtinCommands(&githubAccount, &githubRepo, &reportFileName, &opts)...),
+	}
+
+	err := app.Run(os.Args)
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
 func main() {
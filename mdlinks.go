@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// CommonMark parsers of markdown documents. The second one also links bare URLs, the same as GitHub renders them
+var (
+	markdownParser = goldmark.New().Parser()
+	linkifyParser  = goldmark.New(goldmark.WithExtensions(extension.Linkify)).Parser()
+)
+
+// Collects links of a parsed markdown document. Inline nodes have no positions, so links are found
+// in source after the text segments, which precede them
+type markdownWalker struct {
+	source    []byte
+	checkBare bool // bare URLs and autolinks are links too
+	checkCode bool // links in code are checked too
+	cursor    int  // offset after the last visited segment
+	starts    map[ast.Node]int
+	found     []referenceLink
+	blocks    [][2]int // lines of paragraphs and headings
+	text      [][2]int // plain text segments, where unresolved references are rendered
+	code      [][2]int // code blocks and code spans
+	html      [][2]int // raw HTML blocks and tags
+}
+
+// Returns links of a markdown document (inline, reference-style, raw HTML and optionally bare URLs) and
+// references without definitions
+func markdownDocumentLinks(content []byte, opts *Options) (found, undefined []referenceLink) {
+	w := &markdownWalker{source: content, checkBare: opts.CheckBareUrls, checkCode: opts.CheckCodeLinks, starts: map[ast.Node]int{}}
+	return w.links()
+}
+
+// Returns [start, end) offsets of code blocks and code spans of a markdown document
+func markdownCode(content []byte) [][2]int {
+	w := &markdownWalker{source: content, starts: map[ast.Node]int{}}
+	w.links()
+	return w.code
+}
+
+// Parses source and returns its links (sorted by offset) and references without definitions
+func (w *markdownWalker) links() (found, undefined []referenceLink) {
+	p := markdownParser
+	if w.checkBare {
+		p = linkifyParser
+	}
+	ctx := parser.NewContext()
+	doc := p.Parse(text.NewReader(w.source), parser.WithContext(ctx))
+	ast.Walk(doc, w.visit)
+	// Reference-style links are checked at their definitions
+	for _, def := range referenceDefinitions(w.source, ctx.References(), w.code) {
+		w.found = append(w.found, referenceLink{target: def.inline(), offset: def.offset, end: def.end})
+	}
+	// Raw HTML (centered logos, badges)
+	for _, tag := range htmlLinks(w.source, w.html) {
+		w.found = append(w.found, referenceLink{target: tag.inline(), offset: tag.offset, end: tag.end, image: tag.image})
+	}
+	sort.SliceStable(w.found, func(i, j int) bool { return w.found[i].offset < w.found[j].offset })
+	// Unresolved references ([text][id]) are rendered as plain text
+	for _, block := range w.blocks {
+		for _, m := range referenceUsage.FindAllSubmatchIndex(w.source[block[0]:block[1]], -1) {
+			if !inSpans(w.text, block[0]+m[0]) {
+				continue
+			}
+			id := string(w.source[block[0]+m[4] : block[0]+m[5]])
+			// Collapsed reference [id][] uses link's text as the label
+			if id == "" {
+				id = string(w.source[block[0]+m[2] : block[0]+m[3]])
+			}
+			if _, defined := ctx.Reference(util.ToLinkReference([]byte(id))); !defined {
+				undefined = append(undefined, referenceLink{id: id, offset: block[0] + m[0]})
+			}
+		}
+	}
+	return w.found, undefined
+}
+
+// Visits document's node: positions of blocks and text segments move the cursor, links and images are
+// collected when their destination is known (on exit)
+func (w *markdownWalker) visit(n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		switch n := n.(type) {
+		case *ast.Link:
+			w.closeLink(w.starts[n], n.Destination, false)
+		case *ast.Image:
+			w.closeLink(w.starts[n], n.Destination, true)
+		}
+		return ast.WalkContinue, nil
+	}
+	var span [2]int
+	if n.Type() == ast.TypeBlock && n.Lines().Len() > 0 {
+		lines := n.Lines()
+		span = [2]int{lines.At(0).Start, lines.At(lines.Len() - 1).Stop}
+		w.cursor = span[0]
+	}
+	switch n := n.(type) {
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		// Links in code are examples, unless they are checked explicitly
+		w.code = append(w.code, span)
+		if w.checkCode {
+			w.codeLinks(n.Lines().Sliced(0, n.Lines().Len()))
+		}
+	case *ast.HTMLBlock:
+		w.html = append(w.html, span)
+	case *ast.Paragraph, *ast.Heading, *ast.TextBlock:
+		w.blocks = append(w.blocks, span)
+	case *ast.Text:
+		w.text = append(w.text, [2]int{n.Segment.Start, n.Segment.Stop})
+		w.cursor = n.Segment.Stop
+	case *ast.CodeSpan:
+		var segments []text.Segment
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			if t, ok := c.(*ast.Text); ok {
+				segments = append(segments, t.Segment)
+			}
+		}
+		if len(segments) > 0 {
+			w.code = append(w.code, [2]int{segments[0].Start, segments[len(segments)-1].Stop})
+			w.cursor = segments[len(segments)-1].Stop
+			if w.checkCode {
+				w.codeLinks(segments)
+			}
+		}
+		return ast.WalkSkipChildren, nil
+	case *ast.RawHTML:
+		if n.Segments.Len() > 0 {
+			w.html = append(w.html, [2]int{n.Segments.At(0).Start, n.Segments.At(n.Segments.Len() - 1).Stop})
+			w.cursor = n.Segments.At(n.Segments.Len() - 1).Stop
+		}
+	case *ast.AutoLink:
+		label := n.Label(w.source)
+		i := bytes.Index(w.source[w.cursor:], label)
+		if i < 0 {
+			break
+		}
+		offset := w.cursor + i
+		w.cursor = offset + len(label)
+		if w.checkBare && n.AutoLinkType == ast.AutoLinkURL {
+			// Unbalanced closing parentheses ("(see https://...)") aren't part of the URL
+			url := string(n.URL(w.source))
+			target := trimBareUrl(url)
+			w.found = append(w.found, referenceLink{target: "[" + target + "](" + target + ")", offset: offset, end: w.cursor - len(url) + len(target)})
+		}
+	case *ast.Link, *ast.Image:
+		if i := bytes.IndexByte(w.source[w.cursor:], '['); i >= 0 {
+			w.starts[n] = w.cursor + i
+			w.cursor += i + 1
+		}
+	}
+	return ast.WalkContinue, nil
+}
+
+// Collects link or image, which text starts at offset and ends at the first "]" after its last child.
+// Links are returned as [text](destination), titles are dropped and line breaks of text are collapsed.
+// Reference-style links ([text][id]) are checked at their definitions
+func (w *markdownWalker) closeLink(offset int, destination []byte, image bool) {
+	i := bytes.IndexByte(w.source[w.cursor:], ']')
+	if i < 0 {
+		return
+	}
+	label := w.cursor + i + 1
+	w.cursor = label
+	if label >= len(w.source) || w.source[label] != '(' {
+		return
+	}
+	w.cursor = linkEnd(w.source, label+1)
+	text := string(w.source[offset:label])
+	if strings.ContainsAny(text, "\r\n") {
+		text = strings.Join(strings.Fields(text), " ")
+	}
+	w.found = append(w.found, referenceLink{target: text + "(" + string(destination) + ")", offset: offset, end: w.cursor, image: image})
+}
+
+// Collects links of code block's lines or code span's text, which are parsed as a markdown document
+func (w *markdownWalker) codeLinks(segments []text.Segment) {
+	var code []byte
+	for _, s := range segments {
+		code = append(code, w.source[s.Start:s.Stop]...)
+	}
+	rawOffset := func(offset int) int {
+		for _, s := range segments {
+			if offset <= s.Stop-s.Start {
+				return s.Start + offset
+			}
+			offset -= s.Stop - s.Start
+		}
+		return segments[len(segments)-1].Stop
+	}
+	// Bare URLs of code are never checked
+	embedded := &markdownWalker{source: code, checkCode: true, starts: map[ast.Node]int{}}
+	found, _ := embedded.links()
+	for _, link := range found {
+		link.offset, link.end = rawOffset(link.offset), rawOffset(link.end)
+		w.found = append(w.found, link)
+	}
+}
+
+// Returns offset after the parenthesis, which closes link's destination and optional title (starting at offset)
+func linkEnd(content []byte, offset int) int {
+	depth, quote := 0, byte(0)
+	for i := offset; i < len(content); i++ {
+		switch c := content[i]; {
+		case c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && i > offset && (content[i-1] == ' ' || content[i-1] == '\t' || content[i-1] == '\n'):
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')' && depth == 0:
+			return i + 1
+		case c == ')':
+			depth--
+		}
+	}
+	return len(content)
+}
+
+// Returns true if offset is inside any of [start, end) spans
+func inSpans(spans [][2]int, offset int) bool {
+	for _, s := range spans {
+		if offset >= s[0] && offset < s[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns length of content's first line
+func lineEnd(content []byte) int {
+	if i := bytes.IndexByte(content, '\n'); i >= 0 {
+		return i
+	}
+	return len(content)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Limits total size of file contents, which are held in memory at the same time
+// (by all repositories' extraction workers). Peak RSS is roughly the limit plus
+// extracted links and HTTP buffers
+type memoryBudget struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	limit int64
+	used  int64
+}
+
+func newMemoryBudget(limit int64) *memoryBudget {
+	if limit <= 0 {
+		return nil
+	}
+	b := &memoryBudget{limit: limit}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Waits until n bytes fit into the budget and reserves them. Files bigger than the whole budget
+// are processed alone. Returns function, which releases reserved bytes. Nil budget is unlimited
+func (b *memoryBudget) acquire(n int64) func() {
+	if b == nil {
+		return func() {}
+	}
+	if n > b.limit {
+		n = b.limit
+	}
+	b.mu.Lock()
+	for b.used > 0 && b.used+n > b.limit {
+		b.cond.Wait()
+	}
+	b.used += n
+	b.mu.Unlock()
+	return func() {
+		b.mu.Lock()
+		b.used -= n
+		b.mu.Unlock()
+		b.cond.Broadcast()
+	}
+}
+
+// Parses size with optional K, M or G suffix (e.g. 512M), 0 means unlimited
+func parseByteSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSuffix(strings.TrimSpace(strings.ToUpper(s)), "B"))
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		multiplier = 1 << 30
+	}
+	n, err := strconv.ParseInt(strings.TrimRight(s, "KMG"), 10, 64)
+	if err != nil || n < 0 {
+		return 0, errors.New("[ERR] Invalid size " + s)
+	}
+	return n * multiplier, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// Request methods of link checks
+const (
+	methodHead = "head" // HEAD request, GET if the server rejects it (405/403)
+	methodGet  = "get"
+)
+
+// Parses per-domain request methods (example.com=get)
+func parseMethodRules(method string, rules []string) (map[string]string, error) {
+	if method != methodHead && method != methodGet {
+		return nil, errors.New("[ERR] Unknown request method " + method + " (head or get)")
+	}
+	parsed := map[string]string{}
+	for _, rule := range rules {
+		domain, m, _ := strings.Cut(rule, "=")
+		if m != methodHead && m != methodGet {
+			return nil, errors.New("[ERR] Unknown request method " + m + " of " + domain + " (head or get)")
+		}
+		parsed[strings.ToLower(domain)] = m
+	}
+	return parsed, nil
+}
+
+// Returns request method of URL: method of its domain (subdomains included) or scan's method
+func (opts *Options) requestMethod(rawUrl string) string {
+	if opts == nil {
+		return methodGet
+	}
+	if u, err := url.Parse(rawUrl); err == nil {
+		for host := strings.ToLower(u.Hostname()); host != ""; {
+			if m, ok := opts.MethodRules[host]; ok {
+				return m
+			}
+			_, parent, found := strings.Cut(host, ".")
+			if !found {
+				break
+			}
+			host = parent
+		}
+	}
+	if opts.Method == "" {
+		return methodHead
+	}
+	return opts.Method
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Returns the most likely new location of a missing repository file: a file with the same name
+// (case-insensitive), which shares the most directories with the old path. Ties are resolved by
+// the shortest path. Empty if the archive has no such file
+func (md *MdReport) movedFile(repoPath string) string {
+	base := strings.ToLower(path.Base(repoPath))
+	if md.Tree == nil || base == "/" || base == "." {
+		return ""
+	}
+	oldDirs := strings.Split(strings.Trim(path.Dir(repoPath), "/"), "/")
+	var candidates []string
+	for p, isDir := range md.Tree {
+		if !isDir && p != repoPath && strings.ToLower(path.Base(p)) == base {
+			candidates = append(candidates, p)
+		}
+	}
+	score := func(p string) int {
+		shared := 0
+		for _, dir := range strings.Split(strings.Trim(path.Dir(p), "/"), "/") {
+			for _, old := range oldDirs {
+				if dir != "" && strings.EqualFold(dir, old) {
+					shared++
+					break
+				}
+			}
+		}
+		return shared
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		si, sj := score(candidates[i]), score(candidates[j])
+		if si != sj {
+			return si > sj
+		}
+		if len(candidates[i]) != len(candidates[j]) {
+			return len(candidates[i]) < len(candidates[j])
+		}
+		return candidates[i] < candidates[j]
+	})
+	if len(candidates) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(candidates[0], "/")
+}
+
+// Returns link's target rewritten to the moved file (relative to the linking file, link's query
+// and fragment are kept), so fix mode can replace the broken target
+func movedLinkTarget(link, moved, fpath string) string {
+	_, suffix := splitLinkSuffix(linkTarget(link))
+	rel, err := filepath.Rel(path.Dir("/"+fpath), "/"+moved)
+	if err != nil {
+		return moved + suffix
+	}
+	return filepath.ToSlash(rel) + suffix
+}
+
+// Returns archive path, which differs from repository path in case only. GitHub resolves such
+// links, but case-sensitive hosting (static site generators) doesn't. Empty if there is none
+func (md *MdReport) caseMismatch(repoPath string) string {
+	actual := ""
+	for p := range md.Tree {
+		if p != repoPath && strings.EqualFold(p, repoPath) && (actual == "" || p < actual) {
+			actual = p
+		}
+	}
+	return strings.TrimPrefix(actual, "/")
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Jupyter notebook (nbformat 4). Source is a string or a list of lines
+type notebook struct {
+	Cells []struct {
+		CellType    string                     `json:"cell_type"`
+		Source      json.RawMessage            `json:"source"`
+		Attachments map[string]json.RawMessage `json:"attachments"`
+	} `json:"cells"`
+}
+
+// Part of markdown cell's source: decoded text of a JSON string and offset of its raw content in the notebook
+type notebookSegment struct {
+	text  string
+	start int // offset of the decoded text in cell's source
+	raw   int // offset of the string's content (after the quote) in the notebook
+}
+
+// Returns links of notebook's markdown cells. Every cell is extracted as a separate markdown document,
+// offsets point to the notebook's JSON, so links are reported at their lines. Links to cell attachments
+// (attachment:name) aren't checked, if the attachment exists
+func notebookLinks(content []byte, opts *Options) (links, undefined []referenceLink) {
+	var nb notebook
+	if json.Unmarshal(content, &nb) != nil {
+		return nil, nil
+	}
+	searchFrom := 0
+	for _, cell := range nb.Cells {
+		i := bytes.Index(content[searchFrom:], cell.Source)
+		if i < 0 || len(cell.Source) == 0 {
+			continue
+		}
+		sourceStart := searchFrom + i
+		searchFrom = sourceStart + len(cell.Source)
+		if cell.CellType != "markdown" {
+			continue
+		}
+		segments, text := notebookSegments(content, sourceStart, searchFrom)
+		rawOffset := func(offset int) int {
+			for j := len(segments) - 1; j >= 0; j-- {
+				if s := segments[j]; offset >= s.start {
+					return s.raw + rawJsonOffset(content[s.raw:], offset-s.start)
+				}
+			}
+			return sourceStart
+		}
+		found, missing := markdownDocumentLinks([]byte(text), opts)
+		for _, l := range found {
+			if name := strings.TrimPrefix(linkTarget(l.target), "attachment:"); name != linkTarget(l.target) && cell.Attachments[name] != nil {
+				continue
+			}
+			l.offset, l.end = rawOffset(l.offset), rawOffset(l.end)
+			links = append(links, l)
+		}
+		for _, ref := range missing {
+			ref.offset, ref.end = rawOffset(ref.offset), rawOffset(ref.end)
+			undefined = append(undefined, ref)
+		}
+	}
+	return links, undefined
+}
+
+// Returns JSON strings of cell's source between start and end offsets and their joined text
+func notebookSegments(content []byte, start, end int) ([]notebookSegment, string) {
+	var segments []notebookSegment
+	var text strings.Builder
+	for i := start; i < end; i++ {
+		if content[i] != '"' {
+			continue
+		}
+		// Finds closing quote, which isn't escaped
+		j := i + 1
+		for ; j < end && content[j] != '"'; j++ {
+			if content[j] == '\\' {
+				j++
+			}
+		}
+		var s string
+		if json.Unmarshal(content[i:j+1], &s) == nil {
+			segments = append(segments, notebookSegment{text: s, start: text.Len(), raw: i + 1})
+			text.WriteString(s)
+		}
+		i = j
+	}
+	return segments, text.String()
+}
+
+// Returns offset in JSON string's raw content, which corresponds to offset in its decoded text
+func rawJsonOffset(literal []byte, decoded int) int {
+	i, n := 0, 0
+	for i < len(literal) && n < decoded {
+		switch {
+		case literal[i] != '\\' || i+1 >= len(literal):
+			i++
+			n++
+		case literal[i+1] != 'u' || i+6 > len(literal):
+			i += 2
+			n++
+		default:
+			r, _ := strconv.ParseUint(string(literal[i+2:i+6]), 16, 32)
+			i += 6
+			if utf16.IsSurrogate(rune(r)) && i+6 <= len(literal) && literal[i] == '\\' && literal[i+1] == 'u' {
+				low, _ := strconv.ParseUint(string(literal[i+2:i+6]), 16, 32)
+				i += 6
+				n += utf8.RuneLen(utf16.DecodeRune(rune(r), rune(low)))
+			} else {
+				n += utf8.RuneLen(rune(r))
+			}
+		}
+	}
+	return i
+}
+
+// Returns markdown cells of a notebook as one document, so their headings are anchors
+func notebookMarkdown(content []byte) []byte {
+	var nb notebook
+	if json.Unmarshal(content, &nb) != nil {
+		return nil
+	}
+	var doc bytes.Buffer
+	for _, cell := range nb.Cells {
+		if cell.CellType != "markdown" {
+			continue
+		}
+		var lines []string
+		if json.Unmarshal(cell.Source, &lines) != nil {
+			var source string
+			json.Unmarshal(cell.Source, &source)
+			lines = []string{source}
+		}
+		doc.WriteString(strings.Join(lines, "") + "\n\n")
+	}
+	return doc.Bytes()
+}
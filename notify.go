@@ -0,0 +1,39 @@
+//go:build !nonotify && !minimal
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+func init() {
+	notifyTargets = notify
+}
+
+// Posts JSON report of a finished check to every notification target
+func notify(targets []string, reports []*MdReport, elapsed time.Duration) {
+	if len(targets) == 0 {
+		return
+	}
+	var body bytes.Buffer
+	if err := writeJsonReport(reports, &body, elapsed); err != nil {
+		log.Println("[ERR] Couldn't generate notification:", err)
+		return
+	}
+	for _, target := range targets {
+		resp, err := http.Post(target, "application/json", bytes.NewReader(body.Bytes()))
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				err = fmt.Errorf("response: %d", resp.StatusCode)
+			}
+		}
+		if err != nil {
+			log.Println("[ERR] Couldn't notify "+target+":", err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// Entry points of documentation, if none are configured
+var defaultEntryPoints = []string{"README.md", "README.adoc", "README.rst"}
+
+// Returns true if markdown file is an entry point, which needs no inbound links. Patterns
+// without a slash match file's name in any directory, others match the whole path
+func (opts *Options) isEntryPoint(p string) bool {
+	patterns := defaultEntryPoints
+	if opts != nil && len(opts.EntryPoints) != 0 {
+		patterns = opts.EntryPoints
+	}
+	p = strings.ToLower(strings.TrimPrefix(p, "/"))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimPrefix(pattern, "/"))
+		name := p
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(p)
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Adds a warning for every markdown file (except entry points), which no other document of the
+// repository links to
+func (md *MdReport) reportOrphans() {
+	for _, n := range buildLinkGraph([]*MdReport{md}).Nodes {
+		if n.Orphan {
+			md.addWarning(ReportWarning{Code: codeOrphaned, Path: n.Path, Message: n.Path + " is orphaned: no other document links to it"})
+		}
+	}
+}
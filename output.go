@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Report destination. Empty filename means console
+type ReportOutput struct {
+	Format   string
+	Filename string
+	File     *os.File
+}
+
+// Formats, which are written once all repositories are checked
+var documentFormats = map[string]func(reports []*MdReport, out io.Writer, elapsed time.Duration) error{
+	"json":   writeJsonReport,
+	"html":   writeHtmlReport,
+	"links":  writeLinksReport,
+	"graph":  writeGraphJson,
+	"dot":    writeGraphDot,
+	"github": writeGithubAnnotations,
+	"azure":  writeAzureAnnotations,
+	"sarif": func(reports []*MdReport, out io.Writer, _ time.Duration) error {
+		return writeSarifReport(reports, out)
+	},
+	"junit": func(reports []*MdReport, out io.Writer, _ time.Duration) error {
+		return writeJunitReport(reports, out)
+	},
+}
+
+// Returns true if format is written repository by repository, as soon as each one is checked
+func isStreamingFormat(format string) bool {
+	switch format {
+	case "cli", "file", "md":
+		return true
+	}
+	return false
+}
+
+// Parses output list, where every entry is "format" or "format=filename" (e.g. "md,json=report.json").
+// Markdown report without a filename is written to defaultFilename, other formats - to the console
+func parseOutputs(specs []string, defaultFilename string) ([]*ReportOutput, error) {
+	var outputs []*ReportOutput
+	for _, spec := range specs {
+		for _, entry := range strings.Split(spec, ",") {
+			format, filename, _ := strings.Cut(strings.TrimSpace(entry), "=")
+			if format == "" {
+				continue
+			}
+			if _, ok := documentFormats[format]; !ok && !isStreamingFormat(format) {
+				return nil, fmt.Errorf("[ERR] Unknown output format %s", format)
+			}
+			if filename == "" && (format == "file" || format == "md") {
+				filename = defaultFilename
+			}
+			outputs = append(outputs, &ReportOutput{Format: format, Filename: filename})
+		}
+	}
+	return outputs, nil
+}
+
+// Opens outputs' files (or the console)
+func openOutputs(outputs []*ReportOutput) error {
+	for _, o := range outputs {
+		if o.Filename == "" {
+			o.File = os.Stdout
+			continue
+		}
+		f, err := os.Create(o.Filename)
+		if err != nil {
+			return err
+		}
+		o.File = f
+	}
+	return nil
+}
+
+// Closes outputs' files
+func closeOutputs(outputs []*ReportOutput) {
+	for _, o := range outputs {
+		if o.File != nil && o.File != os.Stdout {
+			o.File.Close()
+		}
+	}
+}
+
+// Writes document formats (JSON, HTML etc.) of all checked repositories
+func writeDocuments(outputs []*ReportOutput, reports []*MdReport, elapsed time.Duration) error {
+	for _, o := range outputs {
+		if write, ok := documentFormats[o.Format]; ok {
+			if err := write(reports, o.File, elapsed); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
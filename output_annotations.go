@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Failed link as a CI annotation
+type annotation struct {
+	Warning bool
+	File    string
+	Line    int
+	Column  int
+	Code    string
+	Message string
+}
+
+// Returns annotations of failed links, execution errors and warnings of checked repositories
+func collectAnnotations(reports []*MdReport) []annotation {
+	var list []annotation
+	for _, md := range reports {
+		if md == nil {
+			continue
+		}
+		if md.State != nil && strings.HasPrefix(*md.State, "[ERR]") {
+			list = append(list, annotation{Message: *md.Repository.Name + ": " + *md.State})
+		}
+		for _, w := range md.Warnings {
+			a := annotation{Warning: true, File: w.Path, Code: w.Code, Message: w.Message}
+			if w.Path != "" {
+				a.Line, a.Column = w.line(), 1
+			}
+			list = append(list, a)
+		}
+		if md.MdFileList == nil {
+			continue
+		}
+		for _, file := range *md.MdFileList {
+			for _, link := range *file.LinkList {
+				if *link.Succeed {
+					continue
+				}
+				state := "no response"
+				if *link.State != 0 {
+					state = fmt.Sprintf("response %d", *link.State)
+				}
+				list = append(list, annotation{
+					Warning: link.IsWarning(),
+					File:    *file.Path,
+					Line:    *link.Line,
+					Column:  *link.Column,
+					Code:    stringValue(link.Code),
+					Message: sarifMessage(*link.Link, state, link.Hint),
+				})
+			}
+		}
+	}
+	return list
+}
+
+// Writes GitHub Actions workflow commands (::error/::warning), which are shown as annotations of changed files
+func writeGithubAnnotations(reports []*MdReport, out io.Writer, _ time.Duration) error {
+	escapeData := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	escapeProperty := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+	for _, a := range collectAnnotations(reports) {
+		command := "error"
+		if a.Warning {
+			command = "warning"
+		}
+		var props []string
+		if a.File != "" {
+			props = append(props, "file="+escapeProperty.Replace(a.File), fmt.Sprintf("line=%d", a.Line), fmt.Sprintf("col=%d", a.Column))
+		}
+		if a.Code != "" {
+			props = append(props, "title="+escapeProperty.Replace(a.Code))
+		}
+		if _, err := fmt.Fprintf(out, "::%s %s::%s\n", command, strings.Join(props, ","), escapeData.Replace(a.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Writes Azure Pipelines logging commands (##vso[task.logissue]), which are shown as build issues
+func writeAzureAnnotations(reports []*MdReport, out io.Writer, _ time.Duration) error {
+	escapeData := strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A")
+	escape := strings.NewReplacer("%", "%AZP25", ";", "%3B", "]", "%5D", "\r", "%0D", "\n", "%0A")
+	for _, a := range collectAnnotations(reports) {
+		props := []string{"type=error"}
+		if a.Warning {
+			props[0] = "type=warning"
+		}
+		if a.File != "" {
+			props = append(props, "sourcepath="+escape.Replace(a.File), fmt.Sprintf("linenumber=%d", a.Line), fmt.Sprintf("columnnumber=%d", a.Column))
+		}
+		if a.Code != "" {
+			props = append(props, "code="+escape.Replace(a.Code))
+		}
+		if _, err := fmt.Fprintf(out, "##vso[task.logissue %s]%s\n", strings.Join(props, ";"), escapeData.Replace(a.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Node kinds of the link graph
+const (
+	graphDocument   = "document"   // markdown file of a checked repository
+	graphFile       = "file"       // other repository file (source code, image etc.)
+	graphRepository = "repository" // repository, which wasn't checked
+	graphDomain     = "domain"     // external site
+)
+
+// Markdown file, repository file, repository or domain
+type GraphNode struct {
+	Id         string `json:"id"`
+	Kind       string `json:"kind"`
+	Repository string `json:"repository,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Inbound    int    `json:"inbound"`          // documents, which link to the node
+	Orphan     bool   `json:"orphan,omitempty"` // document (except entry points), which nothing links to
+}
+
+// Links from a document to a node
+type GraphEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Count  int    `json:"count"`
+	Broken int    `json:"broken,omitempty"`
+}
+
+// Which markdown files link to which files and domains across checked repositories
+type LinkGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// Builds link graph of checked repositories. Links between checked repositories point to their documents
+func buildLinkGraph(reports []*MdReport) *LinkGraph {
+	nodes := map[string]*GraphNode{}
+	edges := map[[2]string]*GraphEdge{}
+	checked := map[string]string{} // repository names by owner/repo
+	entries := map[string]bool{}   // documents, which need no inbound links
+	addNode := func(n GraphNode) *GraphNode {
+		if existing, ok := nodes[n.Id]; ok {
+			return existing
+		}
+		nodes[n.Id] = &n
+		return &n
+	}
+	for _, md := range reports {
+		if md == nil || md.Repository.Name == nil {
+			continue
+		}
+		if u, err := url.Parse(stringValue(md.Repository.HTMLURL)); err == nil && u.Host != "" {
+			checked[strings.ToLower(strings.Trim(u.Path, "/"))] = *md.Repository.Name
+		}
+		for p := range md.Sources {
+			if md.Options.isDocument(p) {
+				n := addNode(GraphNode{Id: graphId(*md.Repository.Name, p), Kind: graphDocument, Repository: *md.Repository.Name, Path: p})
+				entries[n.Id] = md.Options.isEntryPoint(p)
+			}
+		}
+	}
+	for _, md := range reports {
+		if md == nil || md.Repository.Name == nil || md.MdFileList == nil {
+			continue
+		}
+		repo := *md.Repository.Name
+		for _, file := range *md.MdFileList {
+			from := addNode(GraphNode{Id: graphId(repo, *file.Path), Kind: graphDocument, Repository: repo, Path: *file.Path}).Id
+			for _, link := range *file.LinkList {
+				to := graphTarget(md, *file.Path, *link.Link, checked)
+				if to == nil || to.Id == from {
+					continue
+				}
+				to = addNode(*to)
+				e, ok := edges[[2]string{from, to.Id}]
+				if !ok {
+					e = &GraphEdge{From: from, To: to.Id}
+					edges[[2]string{from, to.Id}] = e
+					to.Inbound++
+				}
+				e.Count++
+				if !*link.Succeed {
+					e.Broken++
+				}
+			}
+		}
+	}
+	graph := &LinkGraph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	for _, n := range nodes {
+		n.Orphan = n.Kind == graphDocument && n.Inbound == 0 && !entries[n.Id]
+		graph.Nodes = append(graph.Nodes, *n)
+	}
+	for _, e := range edges {
+		graph.Edges = append(graph.Edges, *e)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].Id < graph.Nodes[j].Id })
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From < graph.Edges[j].From
+		}
+		return graph.Edges[i].To < graph.Edges[j].To
+	})
+	return graph
+}
+
+// Returns node ID of a repository file
+func graphId(repo, p string) string {
+	return repo + ":" + strings.TrimPrefix(p, "/")
+}
+
+// Returns node, which the link points to. Anchors, mailto and other schemes aren't part of the graph
+func graphTarget(md *MdReport, fpath, link string, checked map[string]string) *GraphNode {
+	category, target := classifyLink(md, link)
+	repo := *md.Repository.Name
+	switch category {
+	case linkExternal:
+		return &GraphNode{Id: target, Kind: graphDomain}
+	case linkCrossRepo:
+		u, _ := url.Parse(strings.TrimSpace(linkTarget(link)))
+		if name, ok := checked[strings.ToLower(target)]; ok {
+			if p := blobPath(u); p != "" {
+				return repoFileNode(name, p, md.Options)
+			}
+		}
+		return &GraphNode{Id: target, Kind: graphRepository}
+	case linkInternal, linkAsset:
+		l := strings.TrimSpace(linkTarget(link))
+		u, err := url.Parse(l)
+		if err != nil {
+			return nil
+		}
+		if u.Host != "" {
+			if p := blobPath(u); p != "" {
+				return repoFileNode(repo, p, md.Options)
+			}
+			return nil
+		}
+		rpath := "/"
+		if dir := path.Dir(fpath); dir != "." {
+			rpath = "/" + dir + "/"
+		}
+		return repoFileNode(repo, resolveRepoPath(normalizeSlashes(l), rpath, fpath), md.Options)
+	}
+	return nil
+}
+
+// Returns document or file node of a repository path
+func repoFileNode(repo, p string, opts *Options) *GraphNode {
+	p = strings.TrimPrefix(p, "/")
+	kind := graphFile
+	if opts.isDocument(p) {
+		kind = graphDocument
+	}
+	return &GraphNode{Id: graphId(repo, p), Kind: kind, Repository: repo, Path: p}
+}
+
+// Returns file path of a repository web URL (/<owner>/<repo>/blob/<ref>/<path>)
+func blobPath(u *url.URL) string {
+	parts := strings.SplitN(strings.Trim(u.Path, "/"), "/", 5)
+	if len(parts) < 5 || (parts[2] != "blob" && parts[2] != "tree" && parts[2] != "raw") {
+		return ""
+	}
+	return parts[4]
+}
+
+// Writes link graph as JSON
+func writeGraphJson(reports []*MdReport, out io.Writer, _ time.Duration) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(buildLinkGraph(reports))
+}
+
+// Writes link graph in Graphviz DOT language. Orphaned documents are highlighted, edges with broken links are red
+func writeGraphDot(reports []*MdReport, out io.Writer, _ time.Duration) error {
+	graph := buildLinkGraph(reports)
+	shapes := map[string]string{graphDocument: "box", graphFile: "note", graphRepository: "folder", graphDomain: "ellipse"}
+	var b strings.Builder
+	b.WriteString("digraph links {\n  rankdir=LR;\n")
+	for _, n := range graph.Nodes {
+		attrs := "shape=" + shapes[n.Kind]
+		if n.Orphan {
+			attrs += ", style=filled, fillcolor=lightyellow"
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", strconv.Quote(n.Id), attrs)
+	}
+	for _, e := range graph.Edges {
+		var attrs []string
+		if e.Count > 1 {
+			attrs = append(attrs, "label="+strconv.Quote(strconv.Itoa(e.Count)))
+		}
+		if e.Broken > 0 {
+			attrs = append(attrs, "color=red")
+		}
+		line := "  " + strconv.Quote(e.From) + " -> " + strconv.Quote(e.To)
+		if len(attrs) > 0 {
+			line += " [" + strings.Join(attrs, ", ") + "]"
+		}
+		b.WriteString(line + ";\n")
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(out, b.String())
+	return err
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"time"
+)
+
+const htmlReportStruct = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>gmuv report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292f; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1em; }
+th, td { border: 1px solid #d0d7de; padding: 4px 8px; text-align: left; word-break: break-all; }
+th { background: #f6f8fa; cursor: pointer; user-select: none; }
+.summary span { display: inline-block; margin-right: 2em; font-size: 1.2em; }
+.badge { border-radius: 1em; padding: 1px 8px; color: #fff; font-size: 0.85em; white-space: nowrap; }
+.s0 { background: #6e7781; } .s3 { background: #bf8700; } .s4 { background: #cf222e; } .s5 { background: #8250df; } .s2 { background: #1a7f37; }
+.state { color: #57606a; }
+.warnings { color: #9a6700; }
+</style>
+</head>
+<body>
+<h1>GitHub's Markdown URL Validator</h1>
+<div class="summary">
+<span>Repositories: <b>{{.Repositories}}</b></span>
+<span>Files: <b>{{.Files}}</b></span>
+<span>Links checked: <b>{{.Links}}</b></span>
+<span>Broken: <b>{{.Broken}}</b></span>
+<span>Duration: <b>{{.Duration}}</b></span>
+</div>
+{{range .Reports}}
+<h2><a href="{{.URL}}">{{.Name}}</a></h2>
+{{if .State}}<p class="state">{{.State}}</p>{{end}}
+{{if .Warnings}}<ul class="warnings">
+{{range .Warnings}}<li><code>{{.Code}}</code> {{.Message}}</li>
+{{end}}</ul>{{end}}
+{{if .Links}}
+<table class="sortable">
+<thead><tr><th>File</th><th>Line</th><th>Link</th><th>Status</th><th>Hint</th></tr></thead>
+<tbody>
+{{range .Links}}<tr><td><a href="{{.FileURL}}">{{.File}}</a></td><td>{{.Line}}</td><td>{{.Link}}</td><td data-sort="{{.Status}}"><span class="badge s{{.Class}}">{{if .Status}}{{.Status}}{{else}}no response{{end}}</span>{{if .Code}} <code>{{.Code}}</code>{{end}}</td><td>{{.Hint}}{{if .Blame}} ({{.Blame}}){{end}}</td></tr>
+{{end}}
+</tbody>
+</table>
+{{end}}
+{{end}}
+<script>
+// Sorts table by clicked column (numeric columns are compared as numbers)
+document.querySelectorAll("table.sortable th").forEach(function (th, idx) {
+  th.addEventListener("click", function () {
+    var tbody = th.closest("table").querySelector("tbody");
+    var asc = th.dataset.order !== "asc";
+    th.dataset.order = asc ? "asc" : "desc";
+    var value = function (tr) {
+      var td = tr.children[idx];
+      return td.dataset.sort !== undefined ? td.dataset.sort : td.textContent;
+    };
+    Array.from(tbody.rows).sort(function (a, b) {
+      var x = value(a), y = value(b);
+      var r = (isNaN(x) || isNaN(y)) ? x.localeCompare(y) : x - y;
+      return asc ? r : -r;
+    }).forEach(function (tr) { tbody.appendChild(tr); });
+  });
+});
+</script>
+</body>
+</html>
+`
+
+// HTML report structures
+type HtmlLink struct {
+	File    string
+	FileURL string
+	Line    int
+	Link    string
+	Status  int
+	Code    string
+	Hint    string
+	Blame   *BlameInfo
+	Class   int // status code class (2 for 2xx, 4 for 4xx etc.), 0 if there was no response
+}
+
+type HtmlRepository struct {
+	Name     string
+	URL      string
+	State    string
+	Warnings []ReportWarning
+	Links    []HtmlLink
+}
+
+type HtmlReport struct {
+	Repositories int
+	Files        int
+	Links        int
+	Broken       int
+	Duration     time.Duration
+	Reports      []HtmlRepository
+}
+
+// Writes all checked repositories as a single HTML page with a summary header and sortable tables of broken links
+func writeHtmlReport(reports []*MdReport, out io.Writer, elapsed time.Duration) error {
+	report := HtmlReport{Duration: elapsed.Round(time.Millisecond)}
+	for _, md := range reports {
+		if md == nil {
+			continue
+		}
+		repo := HtmlRepository{Name: *md.Repository.Name, URL: md.RepoUrl(), Warnings: md.Warnings}
+		if md.State != nil {
+			repo.State = *md.State
+		}
+		if md.MdFileList != nil {
+			for _, file := range *md.MdFileList {
+				report.Files++
+				for _, link := range *file.LinkList {
+					report.Links++
+					if *link.Succeed {
+						continue
+					}
+					report.Broken++
+					repo.Links = append(repo.Links, HtmlLink{
+						File:    *file.Path,
+						FileURL: md.FilesUrl() + *file.Path,
+						Line:    *link.Line,
+						Link:    *link.Link,
+						Status:  *link.State,
+						Code:    stringValue(link.Code),
+						Hint:    stringValue(link.Hint),
+						Blame:   link.Blame,
+						Class:   *link.State / 100,
+					})
+				}
+			}
+		}
+		report.Repositories++
+		report.Reports = append(report.Reports, repo)
+	}
+	t := template.Must(template.New("html").Parse(htmlReportStruct))
+	return t.Execute(out, report)
+}
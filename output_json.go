@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// JSON report structures
+type JsonLink struct {
+	Link        string     `json:"link"`
+	Line        int        `json:"line"`
+	Column      int        `json:"column"`
+	Status      int        `json:"status"`
+	Succeed     bool       `json:"succeed"`
+	Error       string     `json:"error,omitempty"`
+	Code        string     `json:"code,omitempty"`
+	Hint        string     `json:"hint,omitempty"`
+	Moved       string     `json:"moved,omitempty"`
+	Context     string     `json:"context,omitempty"`
+	Severity    string     `json:"severity,omitempty"`
+	Blame       *JsonBlame `json:"blame,omitempty"`
+	DurationMs  int64      `json:"duration_ms"`
+	ThrottledMs int64      `json:"throttled_ms,omitempty"`
+	Redirects   []Redirect `json:"redirects,omitempty"`
+}
+
+type JsonBlame struct {
+	Author string    `json:"author"`
+	Email  string    `json:"email,omitempty"`
+	Commit string    `json:"commit"`
+	Date   time.Time `json:"date"`
+}
+
+type JsonFile struct {
+	Path      string     `json:"path"`
+	Generated bool       `json:"generated,omitempty"`
+	URL       string     `json:"url"`
+	Links     []JsonLink `json:"links"`
+}
+
+type JsonRepository struct {
+	Name       string          `json:"name"`
+	URL        string          `json:"url"`
+	Ref        string          `json:"ref,omitempty"`
+	State      string          `json:"state,omitempty"`
+	Warnings   []ReportWarning `json:"warnings,omitempty"`
+	ErrorCodes []string        `json:"error_codes,omitempty"`
+	Sample     *JsonSample     `json:"sample,omitempty"`
+	ReportURL  string          `json:"report_url,omitempty"`
+	AllLinksOK bool            `json:"all_links_ok"`
+	DurationMs int64           `json:"duration_ms"`
+	Files      []JsonFile      `json:"files"`
+}
+
+// Run metadata, which lets to verify how a report was generated
+type JsonMetadata struct {
+	Tool        string `json:"tool"`
+	Version     string `json:"version"`
+	OptionsHash string `json:"options_hash,omitempty"`
+}
+
+// Link counts and broken links estimate of a sampled check
+type JsonSample struct {
+	SampleStats
+	BrokenRate float64 `json:"broken_rate"`
+	CiLow      float64 `json:"broken_rate_ci_low"` // 95% confidence interval
+	CiHigh     float64 `json:"broken_rate_ci_high"`
+}
+
+func newJsonSample(s *SampleStats) *JsonSample {
+	if s == nil {
+		return nil
+	}
+	j := &JsonSample{SampleStats: *s}
+	j.BrokenRate, j.CiLow, j.CiHigh = s.estimate()
+	return j
+}
+
+type JsonReport struct {
+	Metadata     JsonMetadata     `json:"metadata"`
+	Sample       *JsonSample      `json:"sample,omitempty"` // all repositories' sample
+	Generated    time.Time        `json:"generated"`
+	DurationMs   int64            `json:"duration_ms"`
+	Repositories []JsonRepository `json:"repositories"`
+}
+
+// Converts checked repository to JSON report structure
+func newJsonRepository(md *MdReport) JsonRepository {
+	repo := JsonRepository{
+		Name:       *md.Repository.Name,
+		URL:        md.RepoUrl(),
+		Ref:        md.Repository.ref(),
+		AllLinksOK: md.AllLinksOK != nil && *md.AllLinksOK,
+		Files:      []JsonFile{},
+	}
+	if md.State != nil {
+		repo.State = *md.State
+	}
+	repo.Warnings = md.Warnings
+	repo.Sample = newJsonSample(md.Sample)
+	if md.ArtifactUrl != nil {
+		repo.ReportURL = *md.ArtifactUrl
+	}
+	if md.Duration != nil {
+		repo.DurationMs = md.Duration.Milliseconds()
+	}
+	if md.MdFileList == nil {
+		return repo
+	}
+	for _, file := range *md.MdFileList {
+		path := *file.Path
+		if file.DisplayPath != nil {
+			path = *file.DisplayPath
+		}
+		f := JsonFile{Path: path, Generated: file.Generated, URL: md.FilesUrl() + *file.Path, Links: []JsonLink{}}
+		for _, link := range *file.LinkList {
+			f.Links = append(f.Links, JsonLink{
+				Link:        *link.Link,
+				Line:        *link.Line,
+				Column:      *link.Column,
+				Status:      *link.State,
+				Succeed:     *link.Succeed,
+				DurationMs:  link.Duration.Milliseconds(),
+				Error:       stringValue(link.Reason),
+				Code:        stringValue(link.Code),
+				Hint:        stringValue(link.Hint),
+				Moved:       stringValue(link.Moved),
+				Context:     stringValue(link.Context),
+				Severity:    stringValue(link.Severity),
+				Blame:       newJsonBlame(link.Blame),
+				ThrottledMs: durationMs(link.Throttled),
+				Redirects:   link.Redirects,
+			})
+		}
+		repo.Files = append(repo.Files, f)
+	}
+	return repo
+}
+
+// Returns pointed string or empty string for nil pointer
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func durationMs(d *time.Duration) int64 {
+	if d == nil {
+		return 0
+	}
+	return d.Milliseconds()
+}
+
+func newJsonBlame(b *BlameInfo) *JsonBlame {
+	if b == nil {
+		return nil
+	}
+	return &JsonBlame{Author: *b.Author, Email: stringValue(b.Email), Commit: *b.Commit, Date: *b.Date}
+}
+
+// Writes all checked repositories as a single JSON document
+func writeJsonReport(reports []*MdReport, out io.Writer, elapsed time.Duration) error {
+	report := JsonReport{Generated: time.Now().UTC(), DurationMs: elapsed.Milliseconds(), Repositories: []JsonRepository{}}
+	report.Metadata = JsonMetadata{Tool: "gmuv", Version: toolVersion()}
+	var sample *SampleStats
+	for _, md := range reports {
+		if md != nil {
+			report.Metadata.OptionsHash = optionsHash(md.Options)
+			report.Repositories = append(report.Repositories, newJsonRepository(md))
+			if md.Sample != nil {
+				if sample == nil {
+					sample = &SampleStats{}
+				}
+				sample.add(md.Sample)
+			}
+		}
+	}
+	report.Sample = newJsonSample(sample)
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
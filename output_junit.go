@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JUnit XML structures (format understood by Jenkins/GitLab CI)
+type JunitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []JunitTestSuite `xml:"testsuite"`
+}
+
+type JunitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Time      float64         `xml:"time,attr"`
+	SystemOut string          `xml:"system-out,omitempty"`
+	SystemErr string          `xml:"system-err,omitempty"`
+	Cases     []JunitTestCase `xml:"testcase"`
+}
+
+type JunitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *JunitFailure `xml:"failure,omitempty"`
+}
+
+type JunitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// Converts checked repository to a test suite with one test case per checked link. Repository warnings
+// don't fail the suite, they are listed in its output
+func newJunitTestSuite(md *MdReport) JunitTestSuite {
+	suite := JunitTestSuite{Name: *md.Repository.Name}
+	if md.Duration != nil {
+		suite.Time = md.Duration.Seconds()
+	}
+	var warnings []string
+	for _, w := range md.Warnings {
+		warnings = append(warnings, "[WRN] "+w.Code+" "+w.Message)
+	}
+	suite.SystemOut = strings.Join(warnings, "\n")
+	// Repository couldn't be checked at all
+	if md.State != nil && strings.HasPrefix(*md.State, "[ERR]") {
+		suite.SystemErr = *md.State
+	}
+	if md.MdFileList == nil {
+		return suite
+	}
+	for _, file := range *md.MdFileList {
+		for _, link := range *file.LinkList {
+			tc := JunitTestCase{
+				Name:      *link.Link,
+				ClassName: *md.Repository.Name + "/" + *file.Path,
+				Time:      link.Duration.Seconds(),
+			}
+			if !*link.Succeed && !link.IsWarning() {
+				msg := "no response"
+				if *link.State != 0 {
+					msg = fmt.Sprintf("HTTP status %d", *link.State)
+				}
+				if link.Hint != nil {
+					msg += ", " + *link.Hint
+				}
+				errType := "BrokenLink"
+				if link.Code != nil {
+					errType = *link.Code
+				}
+				tc.Failure = &JunitFailure{
+					Message: msg,
+					Type:    errType,
+					Text:    fmt.Sprintf("%s:%d: %s (%s)", *file.Path, *link.Line, *link.Link, msg),
+				}
+				suite.Failures++
+			}
+			suite.Tests++
+			suite.Cases = append(suite.Cases, tc)
+		}
+	}
+	return suite
+}
+
+// Writes all checked repositories as JUnit XML (one test suite per repository)
+func writeJunitReport(reports []*MdReport, out io.Writer) error {
+	suites := JunitTestSuites{Name: "gmuv"}
+	for _, md := range reports {
+		if md != nil {
+			suite := newJunitTestSuite(md)
+			suites.Tests += suite.Tests
+			suites.Failures += suite.Failures
+			suites.Suites = append(suites.Suites, suite)
+		}
+	}
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(out)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Link categories of the classification report
+const (
+	linkInternal  = "internal"   // relative link or absolute link to the same repository
+	linkCrossRepo = "cross-repo" // link to another repository of the same host
+	linkExternal  = "external"   // link to another site (target is its domain)
+	linkAsset     = "asset"      // repository file, which isn't a document (image, archive etc.)
+	linkAnchor    = "anchor"     // fragment-only link to current document
+	linkOther     = "other"      // mailto and other schemes
+)
+
+// Extensions of repository files, which are classified as assets
+var assetExtensions = map[string]bool{
+	"png": true, "jpg": true, "jpeg": true, "gif": true, "svg": true, "webp": true, "ico": true, "bmp": true,
+	"pdf": true, "zip": true, "gz": true, "tgz": true, "mp4": true, "webm": true, "mov": true, "mp3": true,
+	"drawio": true, "vsdx": true, "pptx": true, "docx": true, "xlsx": true,
+}
+
+// Returns link's category and target (domain of external links, owner/repo of cross-repo links)
+func classifyLink(md *MdReport, link string) (category, target string) {
+	l := strings.TrimSpace(linkTarget(link))
+	if strings.HasPrefix(l, "#") {
+		return linkAnchor, ""
+	}
+	u, err := url.Parse(l)
+	if err != nil {
+		return linkOther, ""
+	}
+	p := u.Path
+	switch {
+	case u.Scheme == "" && u.Host == "":
+		// Relative link
+	case u.Scheme != "http" && u.Scheme != "https":
+		return linkOther, u.Scheme
+	default:
+		repo, ok := sameHostRepo(md, u)
+		if !ok {
+			return linkExternal, strings.ToLower(u.Hostname())
+		}
+		if md.Repository.HTMLURL == nil || !strings.EqualFold(strings.TrimSuffix(*md.Repository.HTMLURL, "/"), u.Scheme+"://"+u.Host+"/"+repo) {
+			return linkCrossRepo, repo
+		}
+	}
+	if assetExtensions[strings.ToLower(strings.TrimPrefix(path.Ext(p), "."))] {
+		return linkAsset, ""
+	}
+	return linkInternal, ""
+}
+
+// Returns <owner>/<repo> of a link, if it points to a repository at the checked repository's host
+func sameHostRepo(md *MdReport, u *url.URL) (string, bool) {
+	if md.Repository.HTMLURL == nil {
+		return "", false
+	}
+	base, err := url.Parse(*md.Repository.HTMLURL)
+	if err != nil || !strings.EqualFold(base.Host, u.Host) {
+		return "", false
+	}
+	parts := strings.SplitN(strings.Trim(u.Path, "/"), "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[0] + "/" + parts[1], true
+}
+
+// Writes classification of all discovered links as CSV: counts per repository, file, category and target
+func writeLinksReport(reports []*MdReport, out io.Writer, _ time.Duration) error {
+	type key struct{ repo, file, category, target string }
+	counts := map[key]int{}
+	for _, md := range reports {
+		if md == nil || md.MdFileList == nil {
+			continue
+		}
+		for _, file := range *md.MdFileList {
+			for _, link := range *file.LinkList {
+				category, target := classifyLink(md, *link.Link)
+				counts[key{*md.Repository.Name, *file.Path, category, target}]++
+			}
+		}
+	}
+	keys := make([]key, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.repo != b.repo {
+			return a.repo < b.repo
+		}
+		if a.file != b.file {
+			return a.file < b.file
+		}
+		if a.category != b.category {
+			return a.category < b.category
+		}
+		return a.target < b.target
+	})
+	w := csv.NewWriter(out)
+	w.Write([]string{"repository", "file", "category", "target", "count"})
+	for _, k := range keys {
+		w.Write([]string{k.repo, k.file, k.category, k.target, strconv.Itoa(counts[k])})
+	}
+	w.Flush()
+	return w.Error()
+}
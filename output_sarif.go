@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// SARIF 2.1.0 structures (only fields used by GitHub code scanning)
+// https://docs.github.com/en/code-security/code-scanning/integrating-with-code-scanning/sarif-support-for-code-scanning
+type SarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SarifRun `json:"runs"`
+}
+
+type SarifRun struct {
+	Tool                     SarifTool                 `json:"tool"`
+	ColumnKind               string                    `json:"columnKind"`
+	VersionControlProvenance []SarifVersionControlInfo `json:"versionControlProvenance,omitempty"`
+	Results                  []SarifResult             `json:"results"`
+}
+
+type SarifTool struct {
+	Driver SarifDriver `json:"driver"`
+}
+
+type SarifDriver struct {
+	Name           string      `json:"name"`
+	InformationUri string      `json:"informationUri"`
+	Rules          []SarifRule `json:"rules"`
+}
+
+type SarifRule struct {
+	ID               string        `json:"id"`
+	Name             string        `json:"name,omitempty"`
+	ShortDescription SarifMessage  `json:"shortDescription"`
+	Help             *SarifMessage `json:"help,omitempty"`
+}
+
+type SarifVersionControlInfo struct {
+	RepositoryUri string `json:"repositoryUri"`
+	Branch        string `json:"branch,omitempty"`
+}
+
+type SarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   SarifMessage    `json:"message"`
+	Locations []SarifLocation `json:"locations"`
+}
+
+type SarifMessage struct {
+	Text string `json:"text"`
+}
+
+type SarifLocation struct {
+	PhysicalLocation SarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type SarifPhysicalLocation struct {
+	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+	Region           SarifRegion           `json:"region"`
+}
+
+type SarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type SarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+const sarifBrokenLinkRule = "broken-link"
+
+// Returns SARIF rules: one per finding code of the error catalogue
+func sarifRules() []SarifRule {
+	rules := []SarifRule{{ID: sarifBrokenLinkRule, ShortDescription: SarifMessage{"Broken or inactive Markdown link"}}}
+	for _, c := range errorCatalogue {
+		if c.Code < "GMUV100" {
+			rules = append(rules, SarifRule{ID: c.Code, Name: c.Name, ShortDescription: SarifMessage{c.Description}, Help: &SarifMessage{codeExplanations[c.Code]}})
+		}
+	}
+	return rules
+}
+
+// Converts checked repository to SARIF run. Every broken link and repository warning is a result located at its file/line
+func newSarifRun(md *MdReport) SarifRun {
+	run := SarifRun{
+		Tool: SarifTool{Driver: SarifDriver{
+			Name:           "gmuv",
+			InformationUri: "https://github.com/groovy-sky/github-md-url-check",
+			Rules:          sarifRules(),
+		}},
+		ColumnKind: "unicodeCodePoints",
+		Results:    []SarifResult{},
+	}
+	if md.LocalPath == nil {
+		run.VersionControlProvenance = []SarifVersionControlInfo{{
+			RepositoryUri: *md.Repository.HTMLURL,
+			Branch:        md.Repository.ref(),
+		}}
+	}
+	for _, w := range md.Warnings {
+		result := SarifResult{RuleID: w.Code, Level: "warning", Message: SarifMessage{w.Message}, Locations: []SarifLocation{}}
+		if w.Path != "" {
+			result.Locations = append(result.Locations, SarifLocation{PhysicalLocation: SarifPhysicalLocation{
+				ArtifactLocation: SarifArtifactLocation{URI: w.Path},
+				Region:           SarifRegion{StartLine: w.line(), StartColumn: 1},
+			}})
+		}
+		run.Results = append(run.Results, result)
+	}
+	if md.MdFileList == nil {
+		return run
+	}
+	for _, file := range *md.MdFileList {
+		for _, link := range *file.LinkList {
+			if *link.Succeed {
+				continue
+			}
+			state := "no response"
+			if *link.State != 0 {
+				state = fmt.Sprintf("response %d", *link.State)
+			}
+			level := "error"
+			if link.IsWarning() {
+				level = "warning"
+			}
+			rule := sarifBrokenLinkRule
+			if link.Code != nil {
+				rule = *link.Code
+			}
+			run.Results = append(run.Results, SarifResult{
+				RuleID:  rule,
+				Level:   level,
+				Message: SarifMessage{sarifMessage(*link.Link, state, link.Hint)},
+				Locations: []SarifLocation{{PhysicalLocation: SarifPhysicalLocation{
+					ArtifactLocation: SarifArtifactLocation{URI: *file.Path},
+					Region:           SarifRegion{StartLine: *link.Line, StartColumn: *link.Column},
+				}}},
+			})
+		}
+	}
+	return run
+}
+
+// Writes all checked repositories as a SARIF log (one run per repository)
+func writeSarifReport(reports []*MdReport, out io.Writer) error {
+	log := SarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []SarifRun{},
+	}
+	for _, md := range reports {
+		if md != nil {
+			log.Runs = append(log.Runs, newSarifRun(md))
+		}
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// Returns result's message with remediation hint, if there is one
+func sarifMessage(link, state string, hint *string) string {
+	msg := "Broken link " + link + ": " + state
+	if hint != nil {
+		msg += ". Hint: " + *hint
+	}
+	return msg
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Repeatable flag value, which isn't split by commas (unlike string slices), as regular expressions contain them
+type patternList []string
+
+func (l *patternList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func (l *patternList) String() string {
+	return strings.Join(*l, " ")
+}
+
+// Compiles custom link patterns (--link-pattern)
+func parseLinkPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, errors.New("[ERR] Invalid link pattern " + p + ": " + err.Error())
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Returns links matched by custom patterns, which aren't part of already found links. The link is
+// the first capturing group, which participates in the match (the whole match, if there is none)
+func patternLinks(content []byte, patterns []*regexp.Regexp, covered []referenceLink) []referenceLink {
+	var links []referenceLink
+	for _, re := range patterns {
+		for _, m := range re.FindAllSubmatchIndex(content, -1) {
+			start, end := m[0], m[1]
+			for i := 2; i < len(m); i += 2 {
+				if m[i] >= 0 {
+					start, end = m[i], m[i+1]
+					break
+				}
+			}
+			if start == end || isCovered(covered, start) || isCovered(links, start) {
+				continue
+			}
+			target := string(content[start:end])
+			links = append(links, referenceLink{id: target, target: "[" + target + "](" + target + ")", offset: start, end: end})
+		}
+	}
+	return links
+}
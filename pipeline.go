@@ -0,0 +1,196 @@
+package main
+
+import (
+	"path"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Link found in a markdown file, which is waiting for a check
+type extractedLink struct {
+	url          string
+	line, column int
+	context      string
+	severity     string
+	image        bool
+}
+
+// Markdown file with extracted links. Check results are stored at the same indexes as links
+type extractedFile struct {
+	path      string
+	generated bool
+	total     int // extracted links, including those left out of the sample
+	skipped   int // ignored links and links left out of the sample
+	links     []extractedLink
+	results   []MdLink
+}
+
+// Link check job: link's index in a file
+type linkJob struct {
+	file  *extractedFile
+	index int
+}
+
+// Default number of link checking workers
+const defaultCheckWorkers = 8
+
+// Default limit of concurrent link checks of all repositories
+const defaultConcurrency = 32
+
+// Bounds concurrent link checks of all files and repositories, which are checked at the same time
+type checkPool chan struct{}
+
+func newCheckPool(size int) checkPool {
+	if size < 1 {
+		return nil
+	}
+	return make(checkPool, size)
+}
+
+// Waits for a free slot of the pool. Returned function releases it. Nil pool is unbounded
+func (p checkPool) acquire() func() {
+	if p == nil {
+		return func() {}
+	}
+	p <- struct{}{}
+	return func() { <-p }
+}
+
+// Extracts links of source files and checks them. Extraction workers (CPU-bound) send links to
+// checking workers (network-bound) through a channel, so parsing overlaps with URL checks.
+// Files are added to the report in source order
+func checkSourceFiles(md *MdReport, files []SourceFile) {
+	extractWorkers, checkWorkers := md.Options.ExtractWorkers, md.Options.CheckWorkers
+	if extractWorkers < 1 {
+		extractWorkers = runtime.NumCPU()
+	}
+	if checkWorkers < 1 {
+		checkWorkers = defaultCheckWorkers
+	}
+	sources := make(chan int)
+	jobs := make(chan linkJob, 256)
+	extracted := make([]*extractedFile, len(files))
+	var extractWg, checkWg sync.WaitGroup
+
+	for i := 0; i < checkWorkers; i++ {
+		checkWg.Add(1)
+		go func() {
+			defer checkWg.Done()
+			for job := range jobs {
+				release := md.Options.checks.acquire()
+				job.file.results[job.index] = checkExtractedLink(md, job.file, job.file.links[job.index])
+				release()
+			}
+		}()
+	}
+	for i := 0; i < extractWorkers; i++ {
+		extractWg.Add(1)
+		go func() {
+			defer extractWg.Done()
+			for idx := range sources {
+				file := extractMdFile(md, files[idx])
+				extracted[idx] = file
+				if file == nil {
+					continue
+				}
+				for j := range file.links {
+					jobs <- linkJob{file, j}
+				}
+			}
+		}()
+	}
+	for i := range files {
+		sources <- i
+	}
+	close(sources)
+	extractWg.Wait()
+	close(jobs)
+	checkWg.Wait()
+	// Warnings are added concurrently
+	sort.Slice(md.Warnings, func(i, j int) bool { return md.Warnings[i].Message < md.Warnings[j].Message })
+	if md.Options.sampling != nil {
+		md.Sample = &SampleStats{}
+	}
+
+	for _, file := range extracted {
+		if file == nil {
+			continue
+		}
+		md.Skipped += file.skipped
+		links := file.results
+		var broken int
+		for _, link := range links {
+			if !*link.Succeed && !link.IsWarning() {
+				*md.AllLinksOK = false
+				broken++
+			}
+		}
+		// Links of files, which had none sampled, still count in the total
+		if md.Sample != nil {
+			md.Sample.add(&SampleStats{Total: file.total, Checked: len(links), Broken: broken})
+		}
+		if len(links) == 0 {
+			continue
+		}
+		fileFullPath := file.path
+		displayPath := formatDisplayPath(fileFullPath, md.Options.PathStyle)
+		if md.MdFileList == nil {
+			md.MdFileList = &[]MdFile{}
+		}
+		*md.MdFileList = append(*md.MdFileList, MdFile{&fileFullPath, &links, displayPath, file.generated})
+	}
+}
+
+// Checks extracted link and returns its report
+func checkExtractedLink(md *MdReport, file *extractedFile, l extractedLink) MdLink {
+	fileRelativePath := "/"
+	if dir := path.Dir(file.path); dir != "." {
+		fileRelativePath = "/" + dir + "/"
+	}
+	url, line, column, context, severity := l.url, l.line, l.column, l.context, l.severity
+	start := time.Now()
+	state, ok, reason, hint, code, throttled, redirects := checkMdLink(md, url, fileRelativePath, file.path, l.image)
+	elapsed := time.Since(start)
+	mdLinkVal := MdLink{Link: &url, State: &state, Succeed: &ok, Duration: &elapsed, Line: &line, Column: &column, Context: &context, Severity: &severity}
+	if code == codeMissingFile {
+		repoPath := resolveRepoPath(normalizeSlashes(linkTarget(url)), fileRelativePath, file.path)
+		if moved := md.movedFile(repoPath); moved != "" {
+			target := movedLinkTarget(url, moved, file.path)
+			mdLinkVal.Moved = &target
+			hint = "did you mean " + moved + "? " + hint
+		}
+	}
+	// Links, which differ in case only, work on GitHub, so they don't fail the check. Fix mode corrects the case
+	if code == codeCaseMismatch {
+		severity = severityWarning
+		if actual := md.caseMismatch(resolveRepoPath(normalizeSlashes(linkTarget(url)), fileRelativePath, file.path)); actual != "" {
+			target := movedLinkTarget(url, actual, file.path)
+			mdLinkVal.Moved = &target
+		}
+	}
+	if throttled > 0 {
+		mdLinkVal.Throttled = &throttled
+	}
+	mdLinkVal.Redirects = redirects
+	// Permanently redirected links work, fix mode updates them to the final URL
+	if code == codeMovedPermanently && len(redirects) > 0 {
+		severity = severityWarning
+		target := redirectTarget(url, redirects[len(redirects)-1].Location)
+		mdLinkVal.Moved = &target
+	}
+	if reason != "" {
+		mdLinkVal.Reason = &reason
+	}
+	if hint != "" {
+		mdLinkVal.Hint = &hint
+	}
+	if code != "" {
+		mdLinkVal.Code = &code
+	}
+	if !ok && md.LocalPath != nil {
+		mdLinkVal.Blame = blameLine(*md.LocalPath, file.path, line)
+	}
+	return mdLinkVal
+}
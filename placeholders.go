@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Template variables ({{ var }}, ${VAR}, {var}, %VAR%, $VAR) and <ORG>-style placeholders
+var placeholderVariable = regexp.MustCompile(`\{\{[^}]*\}\}|\$\{[^}]*\}|\{[A-Za-z_][\w.-]*\}|%[A-Z][A-Z_]{2,}%|\$[A-Z][A-Z0-9_]+|<[^<>/\s]+>`)
+
+// Domains reserved for documentation and testing (RFC 2606 and RFC 6761)
+var placeholderDomains = []string{"example.com", "example.net", "example.org", "example", "test", "invalid", "localhost"}
+
+// Host labels of "fill in your own" placeholders (your-domain, your_org, yourcompany). Real hosts starting
+// with "your" (yourls.org, yourbasic.org) aren't placeholders
+var placeholderLabel = regexp.MustCompile(`^your(?:[-_].+|domain|company|site|server|host|org|name|app|project)$`)
+
+// Top-level domains of placeholders, under which any label starting with "your" is a placeholder (yoursite.here)
+var placeholderTlds = []string{"here", "tld"}
+
+// Returns true if link's target is an intentional placeholder: reserved example domain, host
+// like your-domain.here or a template variable
+func isPlaceholderUrl(target string) bool {
+	// Angle brackets may wrap the whole destination ([text](<url>))
+	if inner := strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">"); len(inner) == len(target)-2 && !strings.ContainsAny(inner, "<>") && strings.ContainsAny(inner, "/:") {
+		target = inner
+	}
+	if placeholderVariable.MatchString(target) {
+		return true
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range placeholderDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	labels := strings.Split(host, ".")
+	placeholderTld := false
+	for _, tld := range placeholderTlds {
+		placeholderTld = placeholderTld || labels[len(labels)-1] == tld
+	}
+	for _, label := range labels {
+		if placeholderLabel.MatchString(label) || (placeholderTld && strings.HasPrefix(label, "your")) {
+			return true
+		}
+	}
+	return false
+}
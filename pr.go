@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// GitHub API pull request (only fields used for checking)
+type PullRequest struct {
+	Number int `json:"number"`
+	Head   struct {
+		Sha string `json:"sha"`
+	} `json:"head"`
+}
+
+// File changed by a pull request
+type PullRequestFile struct {
+	Filename string `json:"filename"`
+	Status   string `json:"status"` // added, modified, renamed, removed etc.
+	RawUrl   string `json:"raw_url"`
+}
+
+// GitHub lists up to 3000 files of a pull request
+const maxPullRequestFilePages = 30
+
+// Returns pull request's metadata
+func getPullRequest(account, repo string, number int, opts *Options) (*PullRequest, error) {
+	resp, err := githubGet("https://api.github.com/repos/"+account+"/"+repo+"/pulls/"+strconv.Itoa(number), opts.githubToken())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("[ERR] Couldn't get pull request #%d of %s/%s: %s", number, account, repo, resp.Status)
+	}
+	var pr PullRequest
+	err = json.NewDecoder(resp.Body).Decode(&pr)
+	return &pr, err
+}
+
+// Returns documents (markdown, AsciiDoc, reStructuredText, notebooks), which are added or modified by a pull request
+func getPullRequestFiles(account, repo string, number int, opts *Options) ([]SourceFile, error) {
+	var files []SourceFile
+	for page := 1; page <= maxPullRequestFilePages; page++ {
+		resp, err := githubGet(fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls/%d/files?per_page=100&page=%d", account, repo, number, page), opts.githubToken())
+		if err != nil {
+			return nil, err
+		}
+		var list []PullRequestFile
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("[ERR] Couldn't list files of pull request #%d: %s", number, resp.Status)
+		}
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range list {
+			if f.Status != "removed" && opts.isDocument(f.Filename) {
+				files = append(files, rawSourceFile(f.Filename, f.RawUrl, 0, opts))
+			}
+		}
+		if len(list) < 100 {
+			break
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// Checks only markdown files changed by a pull request. Relative links are resolved against PR's head commit
+func runPrCheck(account, repo string, opts *Options, outputs []*ReportOutput) ([]*MdReport, error) {
+	var mu sync.Mutex
+	if account == "" || repo == "" {
+		return nil, codedError(codeInvalidPath, "Pull request check needs both account and repository names")
+	}
+	repos, err := GetPublicRepos(account, repo, opts)
+	if err != nil {
+		return nil, codedError(codeListFailed, "Couldn't get "+account+"/"+repo+" repository.\n\t"+err.Error())
+	}
+	if len(repos) == 0 {
+		return nil, nil
+	}
+	pr, err := getPullRequest(account, repo, opts.Pr, opts)
+	if err != nil {
+		return nil, err
+	}
+	files, err := getPullRequestFiles(account, repo, opts.Pr, opts)
+	if err != nil {
+		return nil, err
+	}
+	r := repos[0]
+	r.Ref = &pr.Head.Sha
+	setGithubUrls(r)
+	allLinksDefVal := true
+	md := &MdReport{Repository: r, Files: files, AllLinksOK: &allLinksDefVal, Options: opts}
+	if len(files) == 0 {
+		md.setState(fmt.Sprintf("[INF] Pull request #%d doesn't change markdown files.", opts.Pr))
+	}
+	checkMdFiles(md, &mu, outputs)
+	return []*MdReport{md}, nil
+}
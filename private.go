@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Visibility of linked GitHub repositories, looked up once per repository
+type repoVisibility struct {
+	mu      sync.Mutex
+	private map[string]bool
+}
+
+// Returns <owner>/<repo> of a github.com link, if the link points to a private repository, which
+// the token has access to. Without a token private repositories can't be told from missing ones
+func (opts *Options) privateTarget(link string) string {
+	token := opts.githubToken()
+	if token == "" || !isGithubUrl(link) {
+		return ""
+	}
+	u, _ := url.Parse(link)
+	parts := strings.SplitN(strings.Trim(u.Path, "/"), "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return ""
+	}
+	repo := strings.ToLower(parts[0] + "/" + strings.TrimSuffix(parts[1], ".git"))
+	if opts.visibility.isPrivate(repo, token) {
+		return repo
+	}
+	return ""
+}
+
+// Returns true if GitHub API shows the repository as private. Nil cache looks it up every time
+func (v *repoVisibility) isPrivate(repo, token string) bool {
+	if v != nil {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		if private, known := v.private[repo]; known {
+			return private
+		}
+	}
+	private := false
+	if resp, err := githubGet("https://api.github.com/repos/"+repo, token); err == nil {
+		var r struct {
+			Private bool `json:"private"`
+		}
+		private = resp.StatusCode == http.StatusOK && json.NewDecoder(resp.Body).Decode(&r) == nil && r.Private
+		resp.Body.Close()
+	}
+	if v != nil {
+		v.private[repo] = private
+	}
+	return private
+}
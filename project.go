@@ -0,0 +1,295 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Exported items are recognized by this title prefix, other items of the board are never changed
+const projectItemPrefix = "[gmuv] "
+
+// Status field options of exported items (GitHub's default project template)
+const (
+	projectStatusTodo = "Todo"
+	projectStatusDone = "Done"
+)
+
+// GitHub Project (v2), which broken links are exported to as draft issues
+type projectBoard struct {
+	id        string
+	fieldName string
+	field     string            // single select status field
+	options   map[string]string // status option IDs by their names
+	items     map[string]projectItem
+	token     string
+	perRepo   bool // one item per repository instead of one per broken link
+	statuses  bool // status field exists
+}
+
+// Exported draft issue
+type projectItem struct {
+	id      string
+	draftId string
+	body    string
+	status  string
+}
+
+const projectQuery = `query($login: String!, $number: Int!, $field: String!) {
+  %s(login: $login) {
+    projectV2(number: $number) {
+      id
+      field(name: $field) { ... on ProjectV2SingleSelectField { id options { id name } } }
+    }
+  }
+}`
+
+const projectItemsQuery = `query($project: ID!, $cursor: String, $field: String!) {
+  node(id: $project) {
+    ... on ProjectV2 {
+      items(first: 100, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          id
+          content { ... on DraftIssue { id title body } }
+          fieldValueByName(name: $field) { ... on ProjectV2ItemFieldSingleSelectValue { name } }
+        }
+      }
+    }
+  }
+}`
+
+const projectAddMutation = `mutation($project: ID!, $title: String!, $body: String) {
+  addProjectV2DraftIssue(input: {projectId: $project, title: $title, body: $body}) { projectItem { id } }
+}`
+
+const projectUpdateMutation = `mutation($draft: ID!, $body: String) {
+  updateProjectV2DraftIssue(input: {draftIssueId: $draft, body: $body}) { draftIssue { id } }
+}`
+
+const projectStatusMutation = `mutation($project: ID!, $item: ID!, $field: ID!, $option: String!) {
+  updateProjectV2ItemFieldValue(input: {projectId: $project, itemId: $item, fieldId: $field, value: {singleSelectOptionId: $option}}) { projectV2Item { id } }
+}`
+
+// Opens project <owner>/<number> (organization's or user's) and loads its draft issues
+func openProjectBoard(spec, field string, perRepo bool, token string) (*projectBoard, error) {
+	owner, number, found := strings.Cut(spec, "/")
+	n, err := strconv.Atoi(number)
+	if !found || owner == "" || err != nil {
+		return nil, errors.New("[ERR] Project must be specified as <owner>/<number>, e.g. my-org/5")
+	}
+	b := &projectBoard{fieldName: field, options: map[string]string{}, items: map[string]projectItem{}, token: token, perRepo: perRepo}
+	for _, kind := range []string{"organization", "user"} {
+		var result map[string]*struct {
+			ProjectV2 *struct {
+				Id    string `json:"id"`
+				Field *struct {
+					Id      string `json:"id"`
+					Options []struct {
+						Id   string `json:"id"`
+						Name string `json:"name"`
+					} `json:"options"`
+				} `json:"field"`
+			} `json:"projectV2"`
+		}
+		variables := map[string]interface{}{"login": owner, "number": n, "field": field}
+		if githubGraphql(fmt.Sprintf(projectQuery, kind), variables, token, &result) != nil || result[kind] == nil || result[kind].ProjectV2 == nil {
+			continue
+		}
+		project := result[kind].ProjectV2
+		b.id = project.Id
+		if project.Field != nil && project.Field.Id != "" {
+			b.field, b.statuses = project.Field.Id, true
+			for _, o := range project.Field.Options {
+				b.options[o.Name] = o.Id
+			}
+		} else {
+			log.Println("[WRN] Project " + spec + " has no single select field " + field + ", items' status isn't set")
+		}
+		return b, b.loadItems()
+	}
+	return nil, errors.New("[ERR] Couldn't find project " + spec + " (token needs project scope)")
+}
+
+// Loads previously exported draft issues by their titles
+func (b *projectBoard) loadItems() error {
+	var cursor *string
+	for {
+		var result struct {
+			Node struct {
+				Items struct {
+					PageInfo graphqlPageInfo `json:"pageInfo"`
+					Nodes    []struct {
+						Id      string `json:"id"`
+						Content *struct {
+							Id    string `json:"id"`
+							Title string `json:"title"`
+							Body  string `json:"body"`
+						} `json:"content"`
+						Status *struct {
+							Name string `json:"name"`
+						} `json:"fieldValueByName"`
+					} `json:"nodes"`
+				} `json:"items"`
+			} `json:"node"`
+		}
+		variables := map[string]interface{}{"project": b.id, "cursor": cursor, "field": b.fieldName}
+		if err := githubGraphql(projectItemsQuery, variables, b.token, &result); err != nil {
+			return err
+		}
+		for _, item := range result.Node.Items.Nodes {
+			if item.Content == nil || !strings.HasPrefix(item.Content.Title, projectItemPrefix) {
+				continue
+			}
+			exported := projectItem{id: item.Id, draftId: item.Content.Id, body: item.Content.Body}
+			if item.Status != nil {
+				exported.status = item.Status.Name
+			}
+			b.items[item.Content.Title] = exported
+		}
+		if !result.Node.Items.PageInfo.HasNextPage {
+			return nil
+		}
+		cursor = &result.Node.Items.PageInfo.EndCursor
+	}
+}
+
+// Returns title and body of items, which describe repository's broken links
+func (b *projectBoard) reportItems(md *MdReport) map[string]string {
+	items := map[string]string{}
+	if md.MdFileList == nil {
+		return items
+	}
+	repo := *md.Repository.Name
+	var lines []string
+	for _, file := range *md.MdFileList {
+		for _, link := range *file.LinkList {
+			if *link.Succeed || link.IsWarning() {
+				continue
+			}
+			line := fmt.Sprintf("- [ ] `%s` in [%s:%d](%s#L%d) (%s)", linkTarget(*link.Link), *file.Path, *link.Line, md.FilesUrl()+*file.Path, *link.Line, linkStatus(link))
+			if link.Hint != nil {
+				line += ": " + *link.Hint
+			}
+			if b.perRepo {
+				lines = append(lines, line)
+			} else {
+				items[projectItemPrefix+repo+": "+linkTarget(*link.Link)+" in "+*file.Path] = strings.TrimPrefix(line, "- [ ] ")
+			}
+		}
+	}
+	if len(lines) > 0 {
+		items[projectItemPrefix+repo] = fmt.Sprintf("%d broken links in [%s](%s):\n\n%s", len(lines), repo, md.RepoUrl(), strings.Join(lines, "\n"))
+	}
+	return items
+}
+
+// Returns link's check state for humans
+func linkStatus(link MdLink) string {
+	state := "no response"
+	if *link.State != 0 {
+		state = "HTTP " + strconv.Itoa(*link.State)
+	}
+	if link.Code != nil {
+		state += " " + *link.Code
+	}
+	return state
+}
+
+// Returns repository name of exported item's title
+func projectItemRepo(title string) string {
+	repo, _, _ := strings.Cut(strings.TrimPrefix(title, projectItemPrefix), ": ")
+	return repo
+}
+
+// Creates items of new broken links, updates existing ones and moves items of fixed links
+// (of checked repositories) to Done. Items of links broken again are moved back to Todo, other
+// statuses (e.g. In Progress) are kept
+func (b *projectBoard) export(reports []*MdReport) error {
+	current := map[string]string{}
+	checked := map[string]bool{}
+	for _, md := range reports {
+		if md == nil || (md.State != nil && strings.HasPrefix(*md.State, "[ERR]")) {
+			continue
+		}
+		checked[*md.Repository.Name] = true
+		for title, body := range b.reportItems(md) {
+			current[title] = body
+		}
+	}
+	titles := make([]string, 0, len(current))
+	for title := range current {
+		titles = append(titles, title)
+	}
+	sort.Strings(titles)
+	var created, updated, done int
+	for _, title := range titles {
+		body := current[title]
+		item, exists := b.items[title]
+		if !exists {
+			var result struct {
+				AddProjectV2DraftIssue struct {
+					ProjectItem struct {
+						Id string `json:"id"`
+					} `json:"projectItem"`
+				} `json:"addProjectV2DraftIssue"`
+			}
+			if err := githubGraphql(projectAddMutation, map[string]interface{}{"project": b.id, "title": title, "body": body}, b.token, &result); err != nil {
+				return errors.New("[ERR] Couldn't add project item " + title + ": " + err.Error())
+			}
+			item.id = result.AddProjectV2DraftIssue.ProjectItem.Id
+			created++
+		} else if item.body != body {
+			var result interface{}
+			if err := githubGraphql(projectUpdateMutation, map[string]interface{}{"draft": item.draftId, "body": body}, b.token, &result); err != nil {
+				return errors.New("[ERR] Couldn't update project item " + title + ": " + err.Error())
+			}
+			updated++
+		}
+		if !exists || item.status == projectStatusDone {
+			if err := b.setStatus(item.id, projectStatusTodo); err != nil {
+				return err
+			}
+		}
+	}
+	for title, item := range b.items {
+		if _, broken := current[title]; broken || !checked[projectItemRepo(title)] || item.status == projectStatusDone {
+			continue
+		}
+		if err := b.setStatus(item.id, projectStatusDone); err != nil {
+			return err
+		}
+		done++
+	}
+	log.Printf("[INF] Project items: %d created, %d updated, %d fixed\n", created, updated, done)
+	return nil
+}
+
+// Sets item's status field, if project has the field and the option
+func (b *projectBoard) setStatus(item, status string) error {
+	option, ok := b.options[status]
+	if !b.statuses || !ok {
+		return nil
+	}
+	var result interface{}
+	variables := map[string]interface{}{"project": b.id, "item": item, "field": b.field, "option": option}
+	if err := githubGraphql(projectStatusMutation, variables, b.token, &result); err != nil {
+		return errors.New("[ERR] Couldn't set project item's status: " + err.Error())
+	}
+	return nil
+}
+
+// Exports broken links of checked repositories to GitHub Project, if it's specified
+func exportProject(reports []*MdReport, opts *Options) error {
+	if opts.Project == "" {
+		return nil
+	}
+	board, err := openProjectBoard(opts.Project, opts.ProjectField, opts.ProjectItems == "repo", opts.githubToken())
+	if err != nil {
+		return err
+	}
+	return board.export(reports)
+}
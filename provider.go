@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Returns repository list of an account (or a single repository) hosted by a provider
+type repoLister func(account, repo string, opts *Options) ([]*Repository, error)
+
+// Default upper limit of checked repositories, which protects from accidentally scanning huge organizations
+const defaultMaxRepos = 1000
+
+// Repository hosting provider
+type provider struct {
+	list   repoLister
+	urls   func(r *Repository) // sets archive and web URLs of repository's checked ref
+	topics bool                // listed repositories have topics, so --topic can filter them
+	// Returns true if repository has a branch or tag named ref. Nil if provider's URLs accept
+	// any ref, so commits don't need to be told apart
+	namedRef func(r *Repository, ref string, opts *Options) bool
+}
+
+// Supported repository hosting providers. Self-hosted providers register themselves (see registerProvider)
+var providers = map[string]provider{
+	"github": {GetPublicRepos, setGithubUrls, true, nil},
+}
+
+// Abbreviated or full commit SHA. Branches and tags may look the same (e.g. "deadbeef" or "1234567"),
+// so a ref is a commit only if no branch or tag has its name
+var commitSha = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// Returns repositories using provider specified in options (GitHub by default)
+func listRepositories(account, repo string, opts *Options) ([]*Repository, error) {
+	name := opts.Provider
+	if name == "" {
+		name = "github"
+	}
+	p, ok := providers[name]
+	if !ok {
+		return nil, errors.New("[ERR] Unsupported provider " + name + " (or it isn't built in, see noproviders build tag)")
+	}
+	// Without topics the filter would silently drop every repository
+	if len(opts.Topics) > 0 && !p.topics {
+		return nil, errors.New("[ERR] " + name + " repositories have no topics, --topic can't be used")
+	}
+	repos, err := p.list(account, repo, opts)
+	var outRepos []*Repository
+	for _, r := range repos {
+		if !r.hasTopic(opts.Topics) || !opts.includesName(*r.Name) {
+			continue
+		}
+		if opts.Ref != "" {
+			ref := opts.Ref
+			r.Ref = &ref
+			r.RefCommit = commitSha.MatchString(ref) && p.namedRef != nil && !p.namedRef(r, ref, opts)
+		}
+		p.urls(r)
+		outRepos = append(outRepos, r)
+	}
+	if opts.MaxRepos > 0 && len(outRepos) > opts.MaxRepos {
+		log.Printf("[WRN] %s has more than %d repositories, only first %d are checked (see --max-repos)", account, opts.MaxRepos, opts.MaxRepos)
+		outRepos = outRepos[:opts.MaxRepos]
+	}
+	return outRepos, err
+}
+
+// Returns true if listed repositories, which pass name and topic filters, already exceed --max-repos,
+// so listing stops requesting further pages (the limit itself is applied by listRepositories)
+func (opts *Options) enoughRepos(repos []*Repository) bool {
+	if opts.MaxRepos <= 0 {
+		return false
+	}
+	n := 0
+	for _, r := range repos {
+		if r.hasTopic(opts.Topics) && opts.includesName(*r.Name) {
+			n++
+		}
+	}
+	return n > opts.MaxRepos
+}
+
+// Returns true if repository name matches any include pattern (if there are any) and no exclude pattern
+func (opts *Options) includesName(name string) bool {
+	matches := func(patterns []string) bool {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return (len(opts.RepoInclude) == 0 || matches(opts.RepoInclude)) && !matches(opts.RepoExclude)
+}
+
+// Returns error if any repository name pattern is malformed
+func validateRepoPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return errors.New("[ERR] Invalid repository pattern " + p)
+		}
+	}
+	return nil
+}
+
+// Returns true if repository has any of the topics (or no topics are required)
+func (r *Repository) hasTopic(topics []string) bool {
+	if len(topics) == 0 {
+		return true
+	}
+	for _, t := range topics {
+		for _, rt := range r.Topics {
+			if strings.EqualFold(t, rt) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Returns true if repository isn't forked, archived or disabled, unless such repositories are included
+func (opts *Options) includes(r *Repository) bool {
+	isTrue := func(b *bool) bool { return b != nil && *b }
+	return (opts.IncludeForks || !isTrue(r.Fork)) &&
+		(opts.IncludeArchived || !isTrue(r.Archived)) &&
+		(opts.IncludeDisabled || !isTrue(r.Disabled))
+}
+
+func (r *Repository) isPrivate() bool {
+	return r.Private != nil && *r.Private
+}
+
+// Returns checked branch, tag or commit (default branch, if it isn't set). Empty for local directories
+func (r *Repository) ref() string {
+	switch {
+	case r.Ref != nil:
+		return *r.Ref
+	case r.DefaultBranch != nil:
+		return *r.DefaultBranch
+	}
+	return ""
+}
+
+// Sets GitHub's archive and web URLs (any ref can be used as a branch, tag or commit)
+func setGithubUrls(r *Repository) {
+	archiveUrl := *r.HTMLURL + "/archive/refs/heads/" + r.ref() + ".zip"
+	switch {
+	case r.isPrivate() && r.URL != nil:
+		// Web archives of private repositories need a browser session, API accepts token
+		archiveUrl = *r.URL + "/zipball/" + r.ref()
+	case r.Ref != nil:
+		archiveUrl = *r.HTMLURL + "/archive/" + r.ref() + ".zip"
+	}
+	webUrl := *r.HTMLURL + "/blob/" + r.ref()
+	treeUrl := *r.HTMLURL + "/tree/" + r.ref()
+	r.ArchiveUrl, r.WebUrl, r.TreeUrl = &archiveUrl, &webUrl, &treeUrl
+}
+
+// Sends GET request to provider, authorized with provider's own token if one is specified. GitHub token
+// (which --token reads from GITHUB_TOKEN too) is never sent to other providers' servers.
+// Azure DevOps personal access tokens are sent using Basic authentication
+func providerGet(url string, opts *Options) (*http.Response, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case opts.Provider == "" || opts.Provider == "github":
+		if token := opts.githubToken(); token != "" && isGithubHost(request.URL.Hostname()) {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+	case opts.ProviderToken == "":
+	case opts.Provider == "azure":
+		request.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(":"+opts.ProviderToken)))
+	default:
+		request.Header.Set("Authorization", "Bearer "+opts.ProviderToken)
+	}
+	return opts.apiClient().Do(request)
+}
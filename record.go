@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/imroc/req/v3"
+)
+
+// Recorded HTTP interaction. Body is stored next to it (<key>.body)
+type recordedResponse struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// Captures HTTP interactions to a directory or replays them from it. Requests are matched by method and URL,
+// the last recorded response wins
+type httpRecorder struct {
+	dir    string
+	replay bool
+}
+
+// Returns recorder for --record or --replay directory, nil if both are empty
+func newHttpRecorder(record, replay string) (*httpRecorder, error) {
+	switch {
+	case record != "" && replay != "":
+		return nil, errors.New("[ERR] --record and --replay can't be used together")
+	case replay != "":
+		if info, err := os.Stat(replay); err != nil || !info.IsDir() {
+			return nil, errors.New("[ERR] Replay directory " + replay + " doesn't exist")
+		}
+		return &httpRecorder{dir: replay, replay: true}, nil
+	case record != "":
+		if err := os.MkdirAll(record, 0755); err != nil {
+			return nil, err
+		}
+		return &httpRecorder{dir: record}, nil
+	}
+	return nil, nil
+}
+
+// Returns file name (without extension) of request's interaction
+func (rec *httpRecorder) key(r *http.Request) string {
+	sum := sha256.Sum256([]byte(r.Method + " " + r.URL.String()))
+	return filepath.Join(rec.dir, hex.EncodeToString(sum[:16]))
+}
+
+// Wraps transport, so its interactions are recorded (or replayed without network access)
+func (rec *httpRecorder) wrap(next http.RoundTripper) http.RoundTripper {
+	if rec == nil {
+		return next
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return req.HttpRoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if rec.replay {
+			return rec.load(r)
+		}
+		resp, err := next.RoundTrip(r)
+		return rec.store(r, resp, err)
+	})
+}
+
+// Stores interaction and returns response with re-readable body
+func (rec *httpRecorder) store(r *http.Request, resp *http.Response, err error) (*http.Response, error) {
+	interaction := recordedResponse{Method: r.Method, URL: r.URL.String()}
+	var body []byte
+	if err != nil {
+		interaction.Error = err.Error()
+	} else {
+		if body, err = io.ReadAll(resp.Body); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		interaction.StatusCode, interaction.Header = resp.StatusCode, resp.Header
+	}
+	key := rec.key(r)
+	content, _ := json.MarshalIndent(interaction, "", "  ")
+	if os.WriteFile(key+".json", content, 0644) == nil {
+		os.WriteFile(key+".body", body, 0644)
+	}
+	return resp, err
+}
+
+// Returns recorded response of the request
+func (rec *httpRecorder) load(r *http.Request) (*http.Response, error) {
+	key := rec.key(r)
+	content, err := os.ReadFile(key + ".json")
+	if err != nil {
+		return nil, errors.New("no recorded response for " + r.Method + " " + r.URL.String())
+	}
+	var interaction recordedResponse
+	if err := json.Unmarshal(content, &interaction); err != nil {
+		return nil, err
+	}
+	if interaction.Error != "" {
+		return nil, errors.New(interaction.Error)
+	}
+	body, _ := os.ReadFile(key + ".body")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.StatusCode, http.StatusText(interaction.StatusCode)),
+		StatusCode:    interaction.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        interaction.Header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       r,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/imroc/req/v3"
+)
+
+// Default number of followed redirects of a link check
+const defaultMaxRedirects = 10
+
+// Redirect of a link check: response status and the location it redirected to
+type Redirect struct {
+	Status   int    `json:"status"`
+	Location string `json:"location"`
+}
+
+// Returns redirect policy of link checks: up to max redirects are followed, 0 follows none
+// (the redirect response is the link's result)
+func redirectPolicy(max int) req.RedirectPolicy {
+	if max <= 0 {
+		return req.NoRedirectPolicy()
+	}
+	return req.MaxRedirectPolicy(max)
+}
+
+// Returns redirects of response from the first one. Every followed request keeps the response,
+// which redirected to it
+func redirectChain(r *req.Response) []Redirect {
+	if r == nil || r.Response == nil {
+		return nil
+	}
+	var chain []Redirect
+	for q := r.Response.Request; q != nil && q.Response != nil; q = q.Response.Request {
+		chain = append([]Redirect{{Status: q.Response.StatusCode, Location: q.URL.String()}}, chain...)
+	}
+	return chain
+}
+
+// Returns final URL of a redirected link, which keeps link's fragment (fragments aren't sent to servers)
+func redirectTarget(link, final string) string {
+	_, fragment, found := strings.Cut(linkTarget(link), "#")
+	if !found || strings.Contains(final, "#") {
+		return final
+	}
+	return final + "#" + fragment
+}
+
+// Returns true if chain has a permanent redirect (301/308), so the link should be updated
+func hasPermanentRedirect(chain []Redirect) bool {
+	for _, redirect := range chain {
+		if redirect.Status == http.StatusMovedPermanently || redirect.Status == http.StatusPermanentRedirect {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/yuin/goldmark/parser"
+)
+
+// [text][id] and [id][] (images included)
+var referenceUsage = regexp.MustCompile(`\[((?:[^\[\]]|\[[^\[\]]*\])*)\]\[([^\[\]]*)\]`)
+
+// Reference link definition
+type referenceLink struct {
+	id     string
+	target string
+	offset int
+	end    int
+	image  bool // image link (![alt](url) or <img>)
+}
+
+// Returns reference link definitions of a parsed document. Definitions have no nodes, so each one is
+// found in source by its label (outside code)
+func referenceDefinitions(content []byte, refs []parser.Reference, code [][2]int) []referenceLink {
+	var defs []referenceLink
+	for _, ref := range refs {
+		label := append(append([]byte("["), ref.Label()...), "]:"...)
+		for from := 0; from < len(content); {
+			i := bytes.Index(content[from:], label)
+			if i < 0 {
+				break
+			}
+			offset := from + i
+			from = offset + len(label)
+			if inSpans(code, offset) {
+				continue
+			}
+			end := from + lineEnd(content[from:])
+			defs = append(defs, referenceLink{id: string(ref.Label()), target: string(ref.Destination()), offset: offset, end: end})
+			break
+		}
+	}
+	return defs
+}
+
+// Returns reference definition as an inline link, so it's checked like other links
+func (r referenceLink) inline() string {
+	return "[" + r.id + "](" + r.target + ")"
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Converts Windows-style separators to forward slashes
+func normalizeSlashes(s string) string {
+	return strings.ReplaceAll(s, "\\", "/")
+}
+
+// Returns file path for displaying in a report, if it differs from repository (posix) path
+func formatDisplayPath(p, style string) *string {
+	switch style {
+	case "windows":
+		p = strings.ReplaceAll(p, "/", "\\")
+	case "native":
+		p = filepath.FromSlash(p)
+	default:
+		return nil
+	}
+	return &p
+}
+
+// Returns 1-based line and column (in runes) of offset. CRLF and CR line endings are counted as a single line break
+func linePosition(content []byte, offset int) (line, column int) {
+	line, lineStart := 1, 0
+	for i := 0; i < offset && i < len(content); i++ {
+		switch content[i] {
+		case '\n':
+			line++
+			lineStart = i + 1
+		case '\r':
+			if i+1 >= len(content) || content[i+1] != '\n' {
+				line++
+				lineStart = i + 1
+			}
+		}
+	}
+	return line, utf8.RuneCount(content[lineStart:offset]) + 1
+}
+
+// Builds a set of archive paths (relative to repository root, starting with '/').
+// Directories are stored with true value, files with false
+func buildArchiveTree(files []SourceFile) map[string]bool {
+	tree := map[string]bool{"/": true}
+	for _, f := range files {
+		name := "/" + f.Path
+		tree[name] = f.IsDir
+		// Some archives don't contain explicit directory entries
+		for dir := path.Dir(name); dir != "/"; dir = path.Dir(dir) {
+			tree[dir] = true
+		}
+	}
+	return tree
+}
+
+// Returns true if relative links are checked by requesting their web pages. Pages of private repositories
+// need a browser session, so their links are validated against archive tree instead
+func (md *MdReport) checksRelativeUrls() bool {
+	return md.Repository.WebUrl != nil && !md.Repository.isPrivate()
+}
+
+// Returns true if path exists in the archive and is a directory
+func (md *MdReport) isArchiveDir(p string) bool {
+	return md.Tree != nil && md.Tree[p]
+}
+
+// Splits link to a path and a suffix (query and/or fragment)
+func splitLinkSuffix(l string) (target, suffix string) {
+	if i := strings.IndexAny(l, "?#"); i >= 0 {
+		return l[:i], l[i:]
+	}
+	return l, ""
+}
+
+// Returns true if directory contains README file, which GitHub renders instead of directory's listing
+func (md *MdReport) hasArchiveReadme(dir string) bool {
+	for p, isDir := range md.Tree {
+		if isDir || path.Dir(p) != dir {
+			continue
+		}
+		switch strings.ToLower(path.Base(p)) {
+		case "readme.md", "readme.rst", "readme.adoc", "readme.asciidoc":
+			return true
+		}
+	}
+	return false
+}
+
+// Converts a schemeless link to a repository path: './' and '../' are collapsed
+// (never above repository root), '/' points to repository root and
+// fragment-only links point to the current file
+func resolveRepoPath(l, rpath, fpath string) string {
+	target, _ := splitLinkSuffix(l)
+	switch {
+	case target == "":
+		return "/" + fpath
+	case strings.HasPrefix(target, "/"):
+		return path.Clean(target)
+	default:
+		return path.Join(rpath, target)
+	}
+}
+
+// Resolves a schemeless link the same way GitHub's renderer does. Directories are opened as a tree
+func resolveGithubLink(md *MdReport, l, rpath, fpath string) string {
+	target, suffix := splitLinkSuffix(l)
+	p := resolveRepoPath(l, rpath, fpath)
+	if p == "/" || strings.HasSuffix(target, "/") || md.isArchiveDir(p) {
+		return strings.TrimSuffix(*md.Repository.TreeUrl+p, "/") + suffix
+	}
+	return *md.Repository.WebUrl + p + suffix
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// Default retry policy of link checks
+const (
+	defaultMaxAttempts  = 3
+	defaultRetryBackoff = time.Second
+	maxRetryBackoff     = 30 * time.Second
+	maxRetryAfter       = 5 * time.Minute // longer Retry-After delays aren't waited out
+)
+
+// Requests link (throttled per host) and retries transient failures: 429 and 5xx responses and timeouts.
+// Backoff doubles with every attempt, random jitter spreads retries of concurrent checks. Retry-After
+// of 429/503 responses replaces the backoff and pauses all checks of the host. Returns time the check
+// waited for the host's throttle
+func requestLink(url string, opts *Options) (r *req.Response, ok bool, throttled time.Duration, err error) {
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		done := opts.throttle.acquire(url)
+		throttled += time.Since(start)
+		if opts.Http3 {
+			r, ok, err = checkUrlHttp3(url, opts)
+		} else {
+			r, ok, err = checkUrl(url, opts.webClient(false), opts.requestMethod(url), opts.Timeout)
+		}
+		status := 0
+		if r != nil && r.Response != nil {
+			status = r.StatusCode
+		}
+		done(status)
+		if ok || attempt >= opts.MaxAttempts || !isTransient(status, err) {
+			return r, ok, throttled, err
+		}
+		if delay, found := retryAfter(r, time.Now()); found {
+			if delay > maxRetryAfter {
+				return r, ok, throttled, err
+			}
+			// Limiter waits the delay out before the next request of the host
+			if opts.throttle != nil {
+				opts.throttle.pause(url, delay)
+				continue
+			}
+			time.Sleep(delay)
+			throttled += delay
+			continue
+		}
+		time.Sleep(retryBackoff(opts.RetryBackoff, attempt))
+	}
+}
+
+// Returns delay of Retry-After header (seconds or HTTP date) of 429 and 503 responses
+func retryAfter(r *req.Response, now time.Time) (time.Duration, bool) {
+	if r == nil || r.Response == nil || (r.StatusCode != http.StatusTooManyRequests && r.StatusCode != http.StatusServiceUnavailable) {
+		return 0, false
+	}
+	value := r.Header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// Returns true if a failed check might succeed later
+func isTransient(status int, err error) bool {
+	if err != nil {
+		return isTimeout(err)
+	}
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// Returns pause before the next attempt: base doubled by every failed attempt (up to maxRetryBackoff),
+// randomized between its half and whole
+func retryBackoff(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	d := base << uint(attempt-1)
+	if d <= 0 || d > maxRetryBackoff {
+		d = maxRetryBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
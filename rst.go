@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// Inline link with embedded target: `text <target>`_ or anonymous `text <target>`__
+var rstInlineLink = regexp.MustCompile("`([^`<]*?)\\s*<([^`<>]+)>`__?")
+
+// External hyperlink target: .. _name: target, anonymous .. __: target or __ target
+var rstTarget = regexp.MustCompile("(?m)^[ \\t]*(?:\\.\\.[ \\t]+__:|__|\\.\\.[ \\t]+_(`[^`]+`|[^:`\\n]+):)[ \\t]+(\\S+)[ \\t]*$")
+
+// Image and figure directives (optionally substitution definitions) and their :target: option
+var rstImage = regexp.MustCompile(`(?m)^[ \t]*\.\.[ \t]+(?:\|[^|\n]+\|[ \t]+)?(?:image|figure)::[ \t]+(\S+)`)
+var rstImageTarget = regexp.MustCompile(`(?m)^[ \t]+:target:[ \t]+(\S+)`)
+
+// Directive, which content is code
+var rstCodeDirective = regexp.MustCompile(`^\s*\.\.[ \t]+(code-block|code|sourcecode|highlight)::`)
+
+// Explicit markup, which isn't a comment: targets, directives, substitutions and footnotes
+var rstExplicitMarkup = regexp.MustCompile(`^\s*\.\.[ \t]+(_|\||\[|[\w:-]+::)`)
+
+// Returns links of a reStructuredText document: inline links with embedded targets, external hyperlink
+// targets, image targets and bare URLs. Links in literal blocks, code directives, comments and inline
+// literals are skipped. Targets, which refer to other targets (name_), aren't links
+func rstLinks(content []byte) []referenceLink {
+	var links []referenceLink
+	skipped := rstSkippedBlocks(content)
+	ignored := func(offset int) bool {
+		for _, b := range skipped {
+			if offset >= b[0] && offset < b[1] {
+				return true
+			}
+		}
+		lineStart := bytes.LastIndexByte(content[:offset], '\n') + 1
+		return bytes.Count(content[lineStart:offset], []byte("``"))%2 == 1
+	}
+	add := func(text, target string, offset, end int, image bool) {
+		if ignored(offset) || strings.HasSuffix(target, "_") || isCovered(links, offset) {
+			return
+		}
+		if text == "" {
+			text = target
+		}
+		links = append(links, referenceLink{id: text, target: "[" + text + "](" + target + ")", offset: offset, end: end, image: image})
+	}
+	for _, m := range rstInlineLink.FindAllSubmatchIndex(content, -1) {
+		add(string(content[m[2]:m[3]]), strings.Join(strings.Fields(string(content[m[4]:m[5]])), ""), m[0], m[1], false)
+	}
+	for _, m := range rstTarget.FindAllSubmatchIndex(content, -1) {
+		text := "anonymous"
+		if m[2] >= 0 {
+			text = strings.Trim(string(content[m[2]:m[3]]), "`")
+		}
+		add(text, string(content[m[4]:m[5]]), m[4], m[5], false)
+	}
+	for _, m := range rstImage.FindAllSubmatchIndex(content, -1) {
+		add("image", string(content[m[2]:m[3]]), m[2], m[3], true)
+	}
+	for _, m := range rstImageTarget.FindAllSubmatchIndex(content, -1) {
+		add("target", string(content[m[2]:m[3]]), m[2], m[3], false)
+	}
+	for _, loc := range bareUrl.FindAllIndex(content, -1) {
+		target := trimBareUrl(string(content[loc[0]:loc[1]]))
+		add("", target, loc[0], loc[0]+len(target), false)
+	}
+	return links
+}
+
+// Returns [start, end) offsets of literal blocks (after "::"), code directives and comments. Their
+// content is indented deeper than the line, which starts them
+func rstSkippedBlocks(content []byte) [][2]int {
+	var blocks [][2]int
+	start, indent := -1, 0
+	for offset := 0; offset < len(content); {
+		end := offset + lineEnd(content[offset:])
+		line := strings.TrimRight(string(content[offset:end]), " \t\r")
+		lineIndent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if start >= 0 && line != "" && lineIndent <= indent {
+			blocks = append(blocks, [2]int{start, offset})
+			start = -1
+		}
+		if start < 0 && line != "" {
+			trimmed := strings.TrimSpace(line)
+			directive := strings.HasPrefix(trimmed, ".. ")
+			switch {
+			case rstCodeDirective.MatchString(line),
+				directive && !rstExplicitMarkup.MatchString(line),
+				!directive && strings.HasSuffix(trimmed, "::"):
+				start, indent = offset, lineIndent
+			}
+		}
+		offset = end + 1
+	}
+	if start >= 0 {
+		blocks = append(blocks, [2]int{start, len(content)})
+	}
+	return blocks
+}
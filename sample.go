@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Share of links, which are checked in sampling mode. Domain rates override the default rate
+type sampling struct {
+	rate    float64
+	domains map[string]float64
+}
+
+// Parses sampling rates: "10%" (or "0.1") sets the default rate, "<domain>=<rate>" sets domain's rate
+// (subdomains included). Links of domains without a rate are checked with the default rate (all if not set)
+func parseSampling(specs []string) (*sampling, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	s := &sampling{rate: 1, domains: map[string]float64{}}
+	for _, spec := range specs {
+		domain, value, found := strings.Cut(spec, "=")
+		if !found {
+			value, domain = domain, ""
+		}
+		rate, err := parseRate(value)
+		if err != nil {
+			return nil, errors.New("[ERR] Invalid sample rate " + spec + ": " + err.Error())
+		}
+		if domain == "" || domain == "*" {
+			s.rate = rate
+		} else {
+			s.domains[strings.ToLower(domain)] = rate
+		}
+	}
+	return s, nil
+}
+
+// Parses rate as percentage (10%) or fraction (0.1)
+func parseRate(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	percent := strings.HasSuffix(s, "%")
+	rate, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil {
+		return 0, err
+	}
+	if percent {
+		rate /= 100
+	}
+	if rate <= 0 || rate > 1 {
+		return 0, errors.New("rate must be greater than 0% and at most 100%")
+	}
+	return rate, nil
+}
+
+// Returns true if markdown link ([text](url)) is in the sample. Link's target is hashed, so the same
+// link is sampled consistently in every file and run. Nil sampling checks all links
+func (s *sampling) sampled(link string) bool {
+	if s == nil {
+		return true
+	}
+	target := linkTarget(link)
+	rate := s.rate
+	if u, err := url.Parse(target); err == nil && u.Host != "" {
+		// The most specific domain wins
+		host, matched := strings.ToLower(u.Hostname()), ""
+		for domain, r := range s.domains {
+			if (host == domain || strings.HasSuffix(host, "."+domain)) && len(domain) > len(matched) {
+				rate, matched = r, domain
+			}
+		}
+	}
+	h := fnv.New32a()
+	h.Write([]byte(target))
+	return float64(h.Sum32()) < rate*float64(math.MaxUint32+1)
+}
+
+// Link counts of a sampled check
+type SampleStats struct {
+	Total   int `json:"total"`   // all extracted links
+	Checked int `json:"checked"` // sampled links
+	Broken  int `json:"broken"`  // failed sampled links (warnings excluded)
+}
+
+// Adds counts of another check
+func (s *SampleStats) add(other *SampleStats) {
+	s.Total += other.Total
+	s.Checked += other.Checked
+	s.Broken += other.Broken
+}
+
+// Returns estimated share of broken links with its 95% confidence interval (Wilson score)
+func (s *SampleStats) estimate() (rate, low, high float64) {
+	if s.Checked == 0 {
+		return 0, 0, 1
+	}
+	const z = 1.96
+	n := float64(s.Checked)
+	rate = float64(s.Broken) / n
+	center := (rate + z*z/(2*n)) / (1 + z*z/n)
+	margin := z / (1 + z*z/n) * math.Sqrt(rate*(1-rate)/n+z*z/(4*n*n))
+	return rate, math.Max(0, center-margin), math.Min(1, center+margin)
+}
+
+// Returns summary of the sample: estimated broken share and count with confidence interval
+func (s *SampleStats) String() string {
+	rate, low, high := s.estimate()
+	return fmt.Sprintf("Sampled %d of %d links: %.1f%% broken (95%% CI %.1f-%.1f%%), about %d broken links in total",
+		s.Checked, s.Total, rate*100, low*100, high*100, int(math.Round(rate*float64(s.Total))))
+}
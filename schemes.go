@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// Default scheme policy: links are requested over HTTP(S), other well-known schemes can't be
+// validated, so they are skipped. Links with other schemes are reported
+var (
+	defaultSchemes     = []string{"http", "https"}
+	defaultSkipSchemes = []string{"mailto", "tel", "ftp", "irc"}
+)
+
+// Returns link's lower-case scheme. Windows drive letters (C:\docs) aren't schemes
+func linkScheme(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || len(u.Scheme) < 2 {
+		return ""
+	}
+	return strings.ToLower(u.Scheme)
+}
+
+// Returns true if links with the scheme are checked by a request
+func (opts *Options) checksScheme(scheme string) bool {
+	schemes := defaultSchemes
+	if opts != nil && len(opts.Schemes) != 0 {
+		schemes = opts.Schemes
+	}
+	return containsString(schemes, scheme)
+}
+
+// Returns true if links with the scheme are counted as skipped instead of being checked
+func (opts *Options) skipsScheme(scheme string) bool {
+	if scheme == "mailto" && opts != nil && opts.VerifyMailto {
+		return false
+	}
+	schemes := defaultSkipSchemes
+	if opts != nil && opts.SkipSchemes != nil {
+		schemes = opts.SkipSchemes
+	}
+	return scheme != "" && containsString(schemes, scheme)
+}
+
+// Normalizes scheme lists. Only HTTP(S) links can be requested
+func parseSchemes(schemes, skip []string) ([]string, []string, error) {
+	normalize := func(list []string) []string {
+		out := []string{}
+		for _, s := range list {
+			if s = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(s), ":")); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	schemes, skip = normalize(schemes), normalize(skip)
+	for _, s := range schemes {
+		if s != "http" && s != "https" {
+			return nil, nil, errors.New("[ERR] Links with scheme " + s + " can't be checked, add it to --skip-schemes instead")
+		}
+	}
+	return schemes, skip, nil
+}
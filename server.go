@@ -0,0 +1,387 @@
+//go:build !noserver && !minimal
+
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	registerCommand(serveCommand)
+}
+
+// Server mode settings and state
+type Server struct {
+	Listen      string
+	Schedule    time.Duration
+	DrainPeriod time.Duration
+	// Scheduled check target
+	Account    string
+	Repository string
+	Filename   string
+	Options    Options
+	Config     *ServerConfig
+	// Shared storage of jobs and reports (local directories are used if empty)
+	StorageUrl string
+	// Job queue settings
+	QueueDir   string
+	QueueSize  int
+	Workers    int
+	MaxRetries int
+	Queue      *JobQueue
+	Artifacts  ArtifactStore
+	// Role granted to requests without API key ("" - none)
+	AnonymousRole string
+
+	ready int32
+	jobs  sync.WaitGroup
+}
+
+// Returns "serve" command, which runs gmuv as a long-living process (e.g. Kubernetes Deployment)
+func serveCommand(account, repo, filename *string, opts *Options) *cli.Command {
+	s := new(Server)
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:        "listen",
+			Value:       ":8080",
+			Usage:       "Address to listen on",
+			EnvVars:     []string{"GMUV_LISTEN"},
+			Destination: &s.Listen,
+		},
+		&cli.DurationFlag{
+			Name:        "schedule",
+			Value:       0,
+			Usage:       "Interval between scheduled checks of --username account (0 disables scheduling)",
+			EnvVars:     []string{"GMUV_SCHEDULE"},
+			Destination: &s.Schedule,
+		},
+		&cli.DurationFlag{
+			Name:        "drain-period",
+			Value:       30 * time.Second,
+			Usage:       "How long to wait for running checks on SIGTERM",
+			EnvVars:     []string{"GMUV_DRAIN_PERIOD"},
+			Destination: &s.DrainPeriod,
+		},
+		&cli.StringFlag{
+			Name:        "queue-dir",
+			Value:       ".queue",
+			Usage:       "Directory where queued jobs and their reports are persisted",
+			EnvVars:     []string{"GMUV_QUEUE_DIR"},
+			Destination: &s.QueueDir,
+		},
+		&cli.StringFlag{
+			Name:        "storage",
+			Value:       "",
+			Usage:       "Shared storage of jobs and reports: redis://, postgres://, sqlite:// or file:// URL (default: --queue-dir and --reports-dir)",
+			EnvVars:     []string{"GMUV_STORAGE"},
+			Destination: &s.StorageUrl,
+		},
+		&cli.IntFlag{
+			Name:        "queue-size",
+			Value:       1000,
+			Usage:       "Maximum number of waiting jobs, new jobs are rejected when the queue is full",
+			EnvVars:     []string{"GMUV_QUEUE_SIZE"},
+			Destination: &s.QueueSize,
+		},
+		&cli.IntFlag{
+			Name:        "workers",
+			Value:       2,
+			Usage:       "Number of jobs processed concurrently",
+			EnvVars:     []string{"GMUV_WORKERS"},
+			Destination: &s.Workers,
+		},
+		&cli.IntFlag{
+			Name:        "max-retries",
+			Value:       3,
+			Usage:       "How many times a failed job is retried",
+			EnvVars:     []string{"GMUV_MAX_RETRIES"},
+			Destination: &s.MaxRetries,
+		},
+		&cli.StringFlag{
+			Name:        "reports-dir",
+			Value:       ".reports",
+			Usage:       "Directory where finished reports are stored and served from /reports/<owner>/<repo>/<run>",
+			EnvVars:     []string{"GMUV_REPORTS_DIR"},
+			Destination: &s.Artifacts.Dir,
+		},
+		&cli.StringFlag{
+			Name:        "public-url",
+			Value:       "",
+			Usage:       "Server's external URL used in report links (e.g. https://gmuv.example.com)",
+			EnvVars:     []string{"GMUV_PUBLIC_URL"},
+			Destination: &s.Artifacts.PublicUrl,
+		},
+		&cli.IntFlag{
+			Name:        "retention-runs",
+			Value:       30,
+			Usage:       "How many stored runs are kept per repository (0 - unlimited)",
+			EnvVars:     []string{"GMUV_RETENTION_RUNS"},
+			Destination: &s.Artifacts.KeepRuns,
+		},
+		&cli.DurationFlag{
+			Name:        "retention-age",
+			Value:       0,
+			Usage:       "Stored runs older than that are removed (0 - unlimited)",
+			EnvVars:     []string{"GMUV_RETENTION_AGE"},
+			Destination: &s.Artifacts.MaxAge,
+		},
+		&cli.StringFlag{
+			Name:        "anonymous-role",
+			Value:       "",
+			Usage:       "API role granted to requests without API key: read or trigger (by default API key is required)",
+			EnvVars:     []string{"GMUV_ANONYMOUS_ROLE"},
+			Destination: &s.AnonymousRole,
+		},
+	}
+	return &cli.Command{
+		Name:  "serve",
+		Usage: "Run as a server with health endpoints and scheduled checks",
+		Flags: flags,
+		Before: func(c *cli.Context) error {
+			return applyConfigFile(c, c.String("config"), c.String("profile"), flags)
+		},
+		Action: func(c *cli.Context) error {
+			var err error
+			s.Account, s.Repository, s.Filename, s.Options = *account, *repo, *filename, *opts
+			if s.AnonymousRole != "" && s.AnonymousRole != roleRead && s.AnonymousRole != roleTrigger {
+				return cli.Exit("[ERR] Unknown anonymous role "+s.AnonymousRole, 1)
+			}
+			// Checked markdown might be attacker-controlled, so internal networks are denied by default
+			if !c.IsSet("egress-deny") {
+				if s.Options.Egress, err = newEgressPolicy(c.StringSlice("egress-schemes"), []string{"private"}, c.StringSlice("egress-ports")); err != nil {
+					return err
+				}
+			}
+			if s.Config, err = loadServerConfig(c.String("config")); err != nil {
+				return err
+			}
+			return s.Run()
+		},
+	}
+}
+
+// Liveness probe: process is able to serve requests
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok\n"))
+}
+
+// Readiness probe: process accepts new checks (false while draining)
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	if atomic.LoadInt32(&s.ready) == 0 {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ok\n"))
+}
+
+// Returns profile selected by name. Empty name means server's default settings
+func (s *Server) profile(name string) (*Profile, bool) {
+	if name == "" {
+		return nil, true
+	}
+	p, ok := s.Config.Profiles[name]
+	return p, ok
+}
+
+// Returns profile selected by ?profile=<name> parameter or X-Gmuv-Profile header
+func (s *Server) requestProfile(r *http.Request) (string, *Profile, bool) {
+	name := r.URL.Query().Get("profile")
+	if name == "" {
+		name = r.Header.Get("X-Gmuv-Profile")
+	}
+	p, ok := s.profile(name)
+	return name, p, ok
+}
+
+// Checks repositories with profile's settings and notifies profile's targets.
+// Every check uses its own working directory, so parallel checks of the same repository don't collide
+func (s *Server) runProfileCheck(account, repo, name string, profile *Profile) ([]*MdReport, time.Duration, error) {
+	opts := profile.apply(s.Options)
+	workDir, err := os.MkdirTemp(execPath, "check-")
+	if err != nil {
+		if err = os.MkdirAll(execPath, 0755); err == nil {
+			workDir, err = os.MkdirTemp(execPath, "check-")
+		}
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer os.RemoveAll(workDir)
+	opts.WorkDir = workDir
+	// Link results are shared within a single check only
+	opts.results = newResultCache(opts.cache)
+
+	start := time.Now()
+	reports, err := runCheck(account, repo, &opts, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	elapsed := time.Since(start)
+	s.Artifacts.Store(account, name, reports, elapsed)
+	if profile != nil {
+		notifyTargets(profile.Notify, reports, elapsed)
+	}
+	return reports, elapsed, nil
+}
+
+// Checks ?account=<name>[&repository=<name>][&profile=<name>] and responds with JSON report
+func (s *Server) check(w http.ResponseWriter, r *http.Request) {
+	account, repo := r.URL.Query().Get("account"), r.URL.Query().Get("repository")
+	if account == "" {
+		http.Error(w, "account parameter is required", http.StatusBadRequest)
+		return
+	}
+	name, profile, ok := s.requestProfile(r)
+	if !ok {
+		http.Error(w, "unknown profile", http.StatusNotFound)
+		return
+	}
+	if atomic.LoadInt32(&s.ready) == 0 {
+		http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	s.jobs.Add(1)
+	defer s.jobs.Done()
+
+	reports, elapsed, err := s.runProfileCheck(account, repo, name, profile)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	writeJsonReport(reports, w, elapsed)
+}
+
+// Runs scheduled checks of entry until ctx is cancelled
+func (s *Server) schedule(ctx context.Context, entry ScheduleEntry) {
+	every, _ := time.ParseDuration(entry.Every)
+	profile, _ := s.profile(entry.Profile)
+	opts := profile.apply(s.Options)
+	filename := entry.Filename
+	if filename == "" {
+		filename = s.Filename
+	}
+	// Profile's reports are kept separately from other teams
+	if profile != nil && profile.ReportsDir != "" {
+		filename = filepath.Join(profile.ReportsDir, filepath.Base(filename))
+		if err := os.MkdirAll(profile.ReportsDir, 0755); err != nil {
+			log.Println("[ERR] Couldn't create "+profile.ReportsDir+" path:", err)
+		}
+	}
+	if entry.Profile != "" {
+		opts.WorkDir = filepath.Join(opts.WorkDir, "profile-"+entry.Profile)
+	}
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		s.jobs.Add(1)
+		start := time.Now()
+		// Every run requests links again, results aren't shared with other runs and profiles
+		opts.results = newResultCache(opts.cache)
+		reports, err := checkAndReport(entry.Username, entry.Repository, filename, &opts)
+		if err != nil {
+			log.Println("[ERR] Scheduled check of "+entry.Username+" failed:", err)
+		} else {
+			s.Artifacts.Store(entry.Username, entry.Profile, reports, time.Since(start))
+			if profile != nil {
+				notifyTargets(profile.Notify, reports, time.Since(start))
+			}
+		}
+		s.jobs.Done()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Serves HTTP endpoints and scheduled checks until SIGTERM/SIGINT is received.
+// Then stops accepting new checks and waits up to drain period for running ones
+func (s *Server) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	queueStore, err := openStateStorage(s.StorageUrl, s.QueueDir, "jobs/")
+	if err != nil {
+		return err
+	}
+	if s.Queue, err = openJobQueue(queueStore, s.Workers, s.MaxRetries, s.QueueSize); err != nil {
+		return err
+	}
+	if s.Artifacts.Backend, err = openStateStorage(s.StorageUrl, s.Artifacts.Dir, "reports/"); err != nil {
+		return err
+	}
+	// Replicas share verified links through the shared storage, unless --cache sets another one
+	if s.StorageUrl != "" && s.Options.cache == nil {
+		if s.Options.cache, err = newLinkStore(s.StorageUrl, s.Options.CacheTtl); err != nil {
+			return err
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.healthz)
+	mux.HandleFunc("/readyz", s.readyz)
+	mux.HandleFunc("/check", s.authorize(requireRole(roleTrigger), s.check))
+	mux.HandleFunc("/jobs", s.authorize(roleByMethod, s.handleJobs))
+	mux.HandleFunc("/jobs/", s.authorize(roleByMethod, s.handleJobs))
+	mux.HandleFunc("/reports/", s.authorize(requireRole(roleRead), s.Artifacts.handle))
+	mux.HandleFunc("/trends", s.authorize(requireRole(roleRead), s.Artifacts.handleTrends))
+	if len(s.Config.ApiKeys) == 0 && s.AnonymousRole == "" {
+		log.Println("[INF] No API keys are configured, only health endpoints are available")
+	}
+	srv := &http.Server{Addr: s.Listen, Handler: mux}
+
+	errs := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errs <- err
+		}
+	}()
+	if s.Schedule > 0 && s.Account != "" {
+		go s.schedule(ctx, ScheduleEntry{Username: s.Account, Repository: s.Repository, Every: s.Schedule.String()})
+	}
+	for _, entry := range s.Config.Schedules {
+		go s.schedule(ctx, entry)
+	}
+	atomic.StoreInt32(&s.ready, 1)
+	s.processJobs(ctx)
+	log.Println("[INF] Listening on", s.Listen)
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("[INF] Shutting down, draining for", s.DrainPeriod)
+	atomic.StoreInt32(&s.ready, 0)
+	drainCtx, cancel := context.WithTimeout(context.Background(), s.DrainPeriod)
+	defer cancel()
+	if err := srv.Shutdown(drainCtx); err != nil {
+		log.Println("[ERR] Couldn't shut down gracefully:", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		s.jobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-drainCtx.Done():
+		log.Println("[ERR] Drain period is over, running checks were interrupted")
+	}
+	return nil
+}
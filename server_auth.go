@@ -0,0 +1,68 @@
+//go:build !noserver && !minimal
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Returns secret from "Authorization: Bearer <key>" or "X-Api-Key: <key>" header
+func requestSecret(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return r.Header.Get("X-Api-Key")
+}
+
+// Wraps handler with API key check. Required role is returned by role function,
+// so one handler can serve both read-only and triggering methods. Checks are triggered only with
+// key's profiles, read handlers hide jobs and reports of other profiles (see requestKey)
+func (s *Server) authorize(role func(*http.Request) string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		required := role(r)
+		secret := requestSecret(r)
+		if secret == "" {
+			if s.AnonymousRole != "" && roleAllows(s.AnonymousRole, required) {
+				next(w, r)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gmuv"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		for i := range s.Config.ApiKeys {
+			key := &s.Config.ApiKeys[i]
+			if !key.matches(secret) {
+				continue
+			}
+			if !roleAllows(key.Role, required) {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			if profile, _, _ := s.requestProfile(r); required == roleTrigger && !key.allowsProfile(profile) {
+				http.Error(w, "profile is not allowed for this key", http.StatusForbidden)
+				return
+			}
+			next(w, r.WithContext(context.WithValue(r.Context(), apiKeyContext{}, key)))
+			return
+		}
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}
+}
+
+// Returns fixed role required by an endpoint
+func requireRole(role string) func(*http.Request) string {
+	return func(*http.Request) string {
+		return role
+	}
+}
+
+// POST requests trigger checks, all other methods only read
+func roleByMethod(r *http.Request) string {
+	if r.Method == http.MethodPost {
+		return roleTrigger
+	}
+	return roleRead
+}
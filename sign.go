@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Tool's version, set on release builds with -ldflags "-X main.version=<version>"
+var version = ""
+
+const signatureComment = "untrusted comment: gmuv ed25519 signature"
+
+// Returns tool's version: release version or module version when installed with "go install"
+func toolVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "devel"
+}
+
+// Returns hash of options, which affect check results (secrets are excluded), so reports
+// generated with different settings can be told apart
+func optionsHash(opts *Options) string {
+	if opts == nil {
+		return ""
+	}
+	content, err := json.Marshal(opts)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Loads Ed25519 private key from PEM (PKCS #8) file
+func loadSigningKey(filename string) (ed25519.PrivateKey, error) {
+	block, err := readPemFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New(filename + " is not an Ed25519 private key")
+	}
+	return private, nil
+}
+
+// Loads Ed25519 public key from PEM (PKIX) file
+func loadVerifyKey(filename string) (ed25519.PublicKey, error) {
+	block, err := readPemFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New(filename + " is not an Ed25519 public key")
+	}
+	return public, nil
+}
+
+func readPemFile(filename string) (*pem.Block, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New(filename + " doesn't contain PEM data")
+	}
+	return block, nil
+}
+
+// Writes detached signature of filename to <filename>.sig
+func signFile(filename string, key ed25519.PrivateKey) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename+".sig", signature(content, key), 0644)
+}
+
+// Returns detached signature of content
+func signature(content []byte, key ed25519.PrivateKey) []byte {
+	return []byte(signatureComment + "\n" + base64.StdEncoding.EncodeToString(ed25519.Sign(key, content)) + "\n")
+}
+
+// Verifies detached signature of filename
+func verifyFile(filename, sigFilename string, key ed25519.PublicKey) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	sigContent, err := os.ReadFile(sigFilename)
+	if err != nil {
+		return err
+	}
+	// Signature is the last non-empty line, previous lines are comments
+	lines := strings.Split(strings.TrimSpace(string(sigContent)), "\n")
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+	if err != nil {
+		return fmt.Errorf("invalid signature file: %w", err)
+	}
+	if !ed25519.Verify(key, content, signature) {
+		return errors.New("signature verification failed, " + filename + " was altered or signed with another key")
+	}
+	return nil
+}
+
+// Signs JSON report files
+func signOutputs(outputs []*ReportOutput, key ed25519.PrivateKey) error {
+	for _, o := range outputs {
+		if key != nil && o.Format == "json" && o.Filename != "" {
+			if err := signFile(o.Filename, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Returns "keygen" command, which creates Ed25519 key pair for report signing
+func keygenCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "keygen",
+		Usage:     "Generate Ed25519 key pair for report signing (<name>.key and <name>.pub)",
+		ArgsUsage: "<name>",
+		Action: func(c *cli.Context) error {
+			name := c.Args().First()
+			if name == "" {
+				name = "gmuv"
+			}
+			public, private, err := ed25519.GenerateKey(rand.Reader)
+			if err != nil {
+				return err
+			}
+			privateDer, err := x509.MarshalPKCS8PrivateKey(private)
+			if err != nil {
+				return err
+			}
+			publicDer, err := x509.MarshalPKIXPublicKey(public)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(name+".key", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDer}), 0600); err != nil {
+				return err
+			}
+			return os.WriteFile(name+".pub", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDer}), 0644)
+		},
+	}
+}
+
+// Returns "verify" command, which checks report's detached signature
+func verifyCommand() *cli.Command {
+	var keyFile string
+	return &cli.Command{
+		Name:      "verify",
+		Usage:     "Verify signature of a JSON report",
+		ArgsUsage: "<report.json> [<report.json.sig>]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "key",
+				Usage:       "Ed25519 public key (PEM)",
+				Required:    true,
+				Destination: &keyFile,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			report := c.Args().First()
+			if report == "" {
+				return cli.Exit("[ERR] Report filename is not specified", 1)
+			}
+			sig := c.Args().Get(1)
+			if sig == "" {
+				sig = report + ".sig"
+			}
+			key, err := loadVerifyKey(keyFile)
+			if err != nil {
+				return cli.Exit("[ERR] Couldn't load key: "+err.Error(), 1)
+			}
+			if err := verifyFile(report, sig, key); err != nil {
+				return cli.Exit("[ERR] "+err.Error(), 1)
+			}
+			// Show metadata of the verified report
+			var meta struct {
+				Metadata json.RawMessage `json:"metadata"`
+			}
+			content, _ := os.ReadFile(report)
+			json.Unmarshal(content, &meta)
+			var out bytes.Buffer
+			json.Indent(&out, meta.Metadata, "", "  ")
+			fmt.Println("[INF] Signature is valid.", out.String())
+			return nil
+		},
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Converts heading's text to an anchor, which is unique within the document (seen counts previous anchors)
+type slugFunc func(text string, seen map[string]int) string
+
+// Heading slug algorithms of platforms rendering markdown
+var slugAlgorithms = map[string]slugFunc{
+	"github":   githubSlug,
+	"gitlab":   gitlabSlug,
+	"kramdown": kramdownSlug,
+	"goldmark": goldmarkSlug,
+}
+
+var (
+	atxHeading    = regexp.MustCompile(`^ {0,3}#{1,6}(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	setextUnder   = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	codeFence     = regexp.MustCompile("^ {0,3}(```|~~~)")
+	inlineMarkup  = regexp.MustCompile("!?\\[([^\\[\\]]*)\\]\\([^)]*\\)|`|\\*+|<[^>]+>")
+	repeatedDash  = regexp.MustCompile(`-{2,}`)
+	kramdownStart = regexp.MustCompile(`^[^a-zA-Z]+`)
+	htmlAnchor    = regexp.MustCompile(`<[a-zA-Z][^>]*?\s(?:id|name)\s*=\s*["']([^"']+)["']`)
+)
+
+// Returns slug function by its name
+func slugAlgorithm(name string) (slugFunc, error) {
+	if name == "" {
+		name = "github"
+	}
+	slug, ok := slugAlgorithms[name]
+	if !ok {
+		return nil, errors.New("[ERR] Unknown slug algorithm " + name)
+	}
+	return slug, nil
+}
+
+// Returns anchors of document's ATX and setext headings and HTML elements' id/name attributes (code blocks are skipped)
+func headingAnchors(content []byte, slug slugFunc) map[string]bool {
+	anchors := map[string]bool{}
+	seen := map[string]int{}
+	var inFence bool
+	var previous string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), len(content)+1)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case codeFence.MatchString(line):
+			inFence = !inFence
+		case inFence:
+		case htmlAnchor.MatchString(line):
+			for _, m := range htmlAnchor.FindAllStringSubmatch(line, -1) {
+				anchors[m[1]] = true
+			}
+			if atxHeading.MatchString(line) {
+				anchors[slug(headingText(atxHeading.FindStringSubmatch(line)[1]), seen)] = true
+				line = ""
+			}
+		case atxHeading.MatchString(line):
+			anchors[slug(headingText(atxHeading.FindStringSubmatch(line)[1]), seen)] = true
+			line = ""
+		case setextUnder.MatchString(line) && strings.TrimSpace(previous) != "":
+			anchors[slug(headingText(previous), seen)] = true
+			line = ""
+		}
+		previous = line
+	}
+	return anchors
+}
+
+// Returns heading's rendered text: links are replaced by their text, code/emphasis markers and HTML tags are removed
+func headingText(s string) string {
+	return strings.TrimSpace(inlineMarkup.ReplaceAllString(s, "$1"))
+}
+
+// Appends "-<n>" suffix to repeated anchors
+func uniqueSlug(slug string, seen map[string]int) string {
+	n := seen[slug]
+	seen[slug]++
+	if n > 0 {
+		return slug + "-" + strconv.Itoa(n)
+	}
+	return slug
+}
+
+// GitHub: lowercased, everything except letters, digits, spaces, '-' and '_' is removed, spaces become '-'
+func githubSlug(text string, seen map[string]int) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return uniqueSlug(b.String(), seen)
+}
+
+// GitLab: same as GitHub, but repeated hyphens are collapsed
+func gitlabSlug(text string, seen map[string]int) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune('-')
+		}
+	}
+	return uniqueSlug(repeatedDash.ReplaceAllString(b.String(), "-"), seen)
+}
+
+// kramdown (Jekyll): only ASCII letters, digits, spaces and '-' are kept, leading non-letters are removed,
+// spaces become '-'. Empty anchors are named "section"
+func kramdownSlug(text string, seen map[string]int) string {
+	var b strings.Builder
+	for _, r := range text {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ' || r == '-') {
+			b.WriteRune(r)
+		}
+	}
+	slug := strings.ToLower(strings.ReplaceAll(kramdownStart.ReplaceAllString(b.String(), ""), " ", "-"))
+	if slug == "" {
+		slug = "section"
+	}
+	return uniqueSlug(slug, seen)
+}
+
+// goldmark (Hugo): lowercased letters and digits are kept, spaces and '-' become '-',
+// leading/trailing '-' are trimmed. Empty anchors are named "heading"
+func goldmarkSlug(text string, seen map[string]int) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-':
+			b.WriteRune('-')
+		}
+	}
+	slug := strings.Trim(b.String(), "-")
+	if slug == "" {
+		slug = "heading"
+	}
+	return uniqueSlug(slug, seen)
+}
+
+// Returns name of slug algorithm anchors are validated with
+func (md *MdReport) slugName() string {
+	if md.Options.Slug == "" {
+		return "github"
+	}
+	return md.Options.Slug
+}
+
+// Returns link's fragment and target's path (without leading '/'), if link points to a heading
+// of a markdown file, which is checked along with the current one
+func (md *MdReport) crossFileAnchor(l, repoPath string) (anchor, target string, found bool) {
+	_, suffix := splitLinkSuffix(l)
+	i := strings.Index(suffix, "#")
+	if repoPath == "" || i < 0 {
+		return "", "", false
+	}
+	target = strings.TrimPrefix(repoPath, "/")
+	if _, ok := md.Sources[target]; !ok || strings.ToLower(getFileExtension(target)) != "md" {
+		return "", "", false
+	}
+	return suffix[i+1:], target, true
+}
+
+// Returns true if markdown file (repository path without leading '/') has a heading or an HTML element
+// with the anchor. Anchors are computed once per file
+func (md *MdReport) hasAnchor(p, anchor string) bool {
+	if unescaped, err := url.PathUnescape(anchor); err == nil {
+		anchor = unescaped
+	}
+	// GitHub prefixes rendered ids, links might use either form
+	anchor = strings.TrimPrefix(anchor, "user-content-")
+	// Empty fragment points to the top of the document
+	if anchor == "" {
+		return true
+	}
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	if md.Anchors == nil {
+		md.Anchors = map[string]map[string]bool{}
+	}
+	anchors, ok := md.Anchors[p]
+	if !ok {
+		anchors = map[string]bool{}
+		if f, ok := md.Sources[p]; ok && f.Open != nil {
+			if content, err := readAll(f.Open); err == nil {
+				content, _ = decodeContent(content)
+				if strings.ToLower(getFileExtension(p)) == "ipynb" {
+					content = notebookMarkdown(content)
+				}
+				slug, _ := slugAlgorithm(md.Options.Slug)
+				anchors = headingAnchors(content, slug)
+			}
+		}
+		md.Anchors[p] = anchors
+	}
+	return anchors[strings.ToLower(anchor)] || anchors[anchor]
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Checked file: archive entry or local file
+type SourceFile struct {
+	Path    string // relative to repository root, '/'-separated
+	IsDir   bool
+	Open    func() (io.ReadCloser, error)
+	Symlink bool
+	Target  string // symlink's target relative to repository root, empty if it points outside
+	Size    int64  // uncompressed size
+}
+
+// Returns file, which is downloaded from raw URL when it's read
+func rawSourceFile(p, rawUrl string, size int64, opts *Options) SourceFile {
+	return SourceFile{
+		Path: p,
+		Size: size,
+		Open: func() (io.ReadCloser, error) {
+			resp, err := githubGet(rawUrl, opts.githubToken())
+			if err != nil {
+				return nil, err
+			}
+			if resp.StatusCode != http.StatusOK {
+				resp.Body.Close()
+				return nil, errors.New("response " + resp.Status)
+			}
+			return resp.Body, nil
+		},
+	}
+}
+
+// How many symlinks are followed before a chain is treated as a loop
+const maxSymlinkHops = 40
+
+// Returns archive's entries with paths relative to repository root. Flat archives have no top folder
+func listArchiveFiles(files []*zip.File, flat bool) []SourceFile {
+	var list []SourceFile
+	for _, f := range files {
+		// Strip archive's top folder (<repo>-<branch>/). Archives created on Windows might use backslashes as a separator
+		name := strings.TrimPrefix(normalizeSlashes(f.FileHeader.Name), "/")
+		if !flat {
+			_, name, _ = strings.Cut(name, "/")
+		}
+		if name == "" {
+			continue
+		}
+		file := SourceFile{
+			Size:  int64(f.UncompressedSize64),
+			Path:  strings.TrimSuffix(name, "/"),
+			IsDir: f.FileInfo().IsDir() || strings.HasSuffix(name, "/"),
+			Open: func(f *zip.File) func() (io.ReadCloser, error) {
+				return func() (io.ReadCloser, error) { return f.Open() }
+			}(f),
+		}
+		// Symlink entry's content is its target
+		if f.Mode()&fs.ModeSymlink != 0 {
+			file.Symlink = true
+			if target, err := readAll(file.Open); err == nil {
+				file.Target = symlinkTarget(file.Path, normalizeSlashes(string(target)))
+			}
+		}
+		list = append(list, file)
+	}
+	return list
+}
+
+// Walks local directory tree (skipping .git folder) and returns its files
+func listLocalFiles(root string) ([]SourceFile, error) {
+	var list []SourceFile
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		file := SourceFile{
+			Path:  filepath.ToSlash(rel),
+			IsDir: d.IsDir(),
+			Open: func() (io.ReadCloser, error) {
+				return os.Open(p)
+			},
+		}
+		if info, err := d.Info(); err == nil {
+			file.Size = info.Size()
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			file.Symlink = true
+			if target, err := os.Readlink(p); err == nil {
+				if filepath.IsAbs(target) {
+					if target, err = filepath.Rel(root, target); err != nil {
+						target = ".."
+					}
+					target = "/" + filepath.ToSlash(target)
+				}
+				file.Target = symlinkTarget(file.Path, filepath.ToSlash(target))
+			}
+		}
+		list = append(list, file)
+		return nil
+	})
+	return list, err
+}
+
+// Reads whole file
+func readAll(open func() (io.ReadCloser, error)) ([]byte, error) {
+	r, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// Returns symlink's target relative to repository root ('/' - absolute path inside repository).
+// Returns empty string if target is outside of the repository
+func symlinkTarget(link, target string) string {
+	if strings.HasPrefix(target, "/") {
+		target = path.Clean(strings.TrimPrefix(target, "/"))
+	} else {
+		target = path.Join(path.Dir(link), target)
+	}
+	if target == "." || target == ".." || strings.HasPrefix(target, "../") {
+		return ""
+	}
+	return target
+}
+
+// Applies symlink policy to source files. With "follow" policy symlinks are read as their targets
+// (symlinked directories are expanded by one level of real entries, so loops are never walked),
+// otherwise symlinks aren't checked. Dangling, looped and outside pointing symlinks are reported as warnings
+func resolveSymlinks(files []SourceFile, policy string) ([]SourceFile, []ReportWarning) {
+	var list []SourceFile
+	var warnings []ReportWarning
+	byPath := map[string]SourceFile{}
+	for _, f := range files {
+		byPath[f.Path] = f
+	}
+	for _, f := range files {
+		if !f.Symlink {
+			list = append(list, f)
+			continue
+		}
+		target, problem, code := f, "", ""
+		for hops := 0; target.Symlink && problem == ""; hops++ {
+			next, ok := byPath[target.Target]
+			switch {
+			case target.Target == "":
+				problem, code = " is a symlink pointing outside of the repository", codeOutsideSymlink
+			case !ok:
+				problem, code = " is a dangling symlink", codeDanglingSymlink
+			case hops == maxSymlinkHops:
+				problem, code = " is a symlink loop", codeSymlinkLoop
+			default:
+				target = next
+			}
+		}
+		if problem != "" {
+			warnings = append(warnings, ReportWarning{Code: code, Path: f.Path, Message: f.Path + problem})
+			continue
+		}
+		if policy != "follow" {
+			continue
+		}
+		if !target.IsDir {
+			list = append(list, SourceFile{Path: f.Path, Open: target.Open, Size: target.Size})
+			continue
+		}
+		list = append(list, SourceFile{Path: f.Path, IsDir: true})
+		for _, child := range files {
+			if !child.Symlink && strings.HasPrefix(child.Path, target.Path+"/") {
+				list = append(list, SourceFile{Path: f.Path + strings.TrimPrefix(child.Path, target.Path), IsDir: child.IsDir, Open: child.Open, Size: child.Size})
+			}
+		}
+	}
+	return list, warnings
+}
+
+// Returns warnings about files, which paths differ only by case (only one of them
+// can exist on case-insensitive file systems and links to them are ambiguous)
+func findCaseDuplicates(files []SourceFile) []ReportWarning {
+	var warnings []ReportWarning
+	byName := map[string][]string{}
+	var names []string
+	for _, f := range files {
+		name := strings.ToLower(f.Path)
+		if len(byName[name]) == 0 {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], f.Path)
+	}
+	for _, name := range names {
+		if paths := byName[name]; len(paths) > 1 {
+			warnings = append(warnings, ReportWarning{Code: codeCaseDuplicate, Path: paths[0], Message: "paths differ only by case: " + strings.Join(paths, ", ")})
+		}
+	}
+	return warnings
+}
+
+// Reads whole content, allocating buffer of known size at once (instead of growing it)
+func readSized(r io.Reader, size int64) ([]byte, error) {
+	var buf bytes.Buffer
+	if size > 0 {
+		buf.Grow(int(size) + bytes.MinRead)
+	}
+	_, err := buf.ReadFrom(r)
+	return buf.Bytes(), err
+}
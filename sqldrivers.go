@@ -0,0 +1,9 @@
+//go:build !nosql && !minimal
+
+package main
+
+// Database drivers of SQL storage backends (sqlite:// and postgres:// URLs)
+import (
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
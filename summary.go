@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Link counts of a whole run
+type runSummary struct {
+	Errors   int // broken links (error severity) and failed repository checks
+	Warnings int // broken links with warning severity and report warnings
+	Ok       int
+	Skipped  int // ignored links and links left out of the sample
+	Duration time.Duration
+}
+
+// Counts results of all checked repositories
+func summarize(reports []*MdReport, elapsed time.Duration) runSummary {
+	s := runSummary{Duration: elapsed}
+	for _, md := range reports {
+		if md == nil {
+			continue
+		}
+		if md.State != nil && strings.HasPrefix(*md.State, "[ERR]") {
+			s.Errors++
+		}
+		s.Warnings += len(md.Warnings)
+		s.Skipped += md.Skipped
+		if md.MdFileList == nil {
+			continue
+		}
+		for _, file := range *md.MdFileList {
+			for _, link := range *file.LinkList {
+				switch {
+				case *link.Succeed:
+					s.Ok++
+				case link.IsWarning():
+					s.Warnings++
+				default:
+					s.Errors++
+				}
+			}
+		}
+	}
+	return s
+}
+
+// Returns the summary as a single greppable line
+func (s runSummary) String() string {
+	return fmt.Sprintf("gmuv: %d errors, %d warnings, %d links ok, %d skipped in %s", s.Errors, s.Warnings, s.Ok, s.Skipped, s.Duration.Round(time.Second))
+}
+
+// Prints run summary to stderr (so it's never mixed with a report on stdout) and returns exit error,
+// if errors or warnings exceed their thresholds (negative threshold is never exceeded)
+func exitSummary(reports []*MdReport, elapsed time.Duration, opts *Options) error {
+	s := summarize(reports, elapsed)
+	fmt.Fprintln(os.Stderr, s)
+	if opts.MaxErrors >= 0 && s.Errors > opts.MaxErrors {
+		return cli.Exit(fmt.Sprintf("[ERR] %d errors exceed the limit of %d", s.Errors, opts.MaxErrors), 1)
+	}
+	if opts.MaxWarnings >= 0 && s.Warnings > opts.MaxWarnings {
+		return cli.Exit(fmt.Sprintf("[ERR] %d warnings exceed the limit of %d", s.Warnings, opts.MaxWarnings), 1)
+	}
+	return nil
+}
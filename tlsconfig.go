@@ -0,0 +1,56 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"log"
+	"os"
+)
+
+// TLS versions of --min-tls-version
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Returns true if link checks don't verify TLS certificates
+func (opts *Options) insecureTls() bool {
+	return opts != nil && opts.tls != nil && opts.tls.InsecureSkipVerify
+}
+
+// Returns TLS configuration of link checks: certificates of caCert file are trusted in addition to
+// system CAs, insecure skips certificate verification. Nil if defaults aren't changed
+func newTlsConfig(insecure bool, caCert, minVersion string) (*tls.Config, error) {
+	if !insecure && caCert == "" && minVersion == "" {
+		return nil, nil
+	}
+	config := &tls.Config{InsecureSkipVerify: insecure}
+	if minVersion != "" {
+		version, ok := tlsVersions[minVersion]
+		if !ok {
+			return nil, errors.New("[ERR] Unknown TLS version " + minVersion + " (1.0, 1.1, 1.2 or 1.3)")
+		}
+		config.MinVersion = version
+	}
+	if caCert != "" {
+		content, err := os.ReadFile(caCert)
+		if err != nil {
+			return nil, errors.New("[ERR] Couldn't read CA certificates: " + err.Error())
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(content) {
+			return nil, errors.New("[ERR] " + caCert + " contains no PEM certificates")
+		}
+		config.RootCAs = pool
+	}
+	if insecure {
+		log.Println("[WRN] TLS certificates of checked links aren't verified")
+	}
+	return config, nil
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Repository's metrics in a single stored run
+type TrendPoint struct {
+	Run             time.Time `json:"run"`
+	Links           int       `json:"links"`
+	Broken          int       `json:"broken"`
+	NewBroken       int       `json:"new_broken"`
+	Fixed           int       `json:"fixed"`
+	NewBreakageRate float64   `json:"new_breakage_rate"`      // share of links broken since previous run
+	MeanTimeToFix   float64   `json:"mean_time_to_fix_hours"` // for links fixed up to this run
+}
+
+// Repository's metrics across stored runs (oldest run first)
+type RepoTrend struct {
+	Owner           string       `json:"owner"`
+	Repository      string       `json:"repository"`
+	Runs            []TrendPoint `json:"runs"`
+	OpenBroken      int          `json:"open_broken"`
+	NewBreakageRate float64      `json:"new_breakage_rate"` // average over runs
+	MeanTimeToFix   float64      `json:"mean_time_to_fix_hours"`
+}
+
+// Computes trends of every repository stored in the artifact store from runs, which the key may read
+// (nil key reads all runs). Repositories without such runs are left out
+func (a *ArtifactStore) trends(apiKey *ApiKey) ([]RepoTrend, error) {
+	keys, err := a.storage().List("")
+	if err != nil {
+		return nil, err
+	}
+	trends := []RepoTrend{}
+	var last string
+	for _, key := range keys {
+		parts := strings.Split(key, "/")
+		if len(parts) != 4 || parts[0]+"/"+parts[1] == last {
+			continue
+		}
+		last = parts[0] + "/" + parts[1]
+		if trend := a.repoTrend(parts[0], parts[1], apiKey); apiKey == nil || len(apiKey.Profiles) == 0 || len(trend.Runs) > 0 {
+			trends = append(trends, trend)
+		}
+	}
+	return trends, nil
+}
+
+// Compares repository's consecutive runs. Broken link is identified by its file and link text,
+// so it's fixed when it disappears or succeeds in a later run
+func (a *ArtifactStore) repoTrend(owner, repo string, key *ApiKey) RepoTrend {
+	trend := RepoTrend{Owner: owner, Repository: repo, Runs: []TrendPoint{}}
+	runs := a.allowedRuns(owner, repo, key)
+	firstSeen := map[string]time.Time{}
+	var fixed int
+	var fixTime time.Duration
+	var rates float64
+	for i := len(runs) - 1; i >= 0; i-- {
+		started, err := runTime(runs[i])
+		if err != nil {
+			continue
+		}
+		var report JsonReport
+		content, err := a.storage().Get(owner + "/" + repo + "/" + runs[i] + "/report.json")
+		if err != nil || json.Unmarshal(content, &report) != nil {
+			continue
+		}
+		point := TrendPoint{Run: started}
+		broken := map[string]bool{}
+		for _, r := range report.Repositories {
+			for _, f := range r.Files {
+				for _, l := range f.Links {
+					point.Links++
+					if !l.Succeed {
+						broken[f.Path+"\x00"+l.Link] = true
+					}
+				}
+			}
+		}
+		point.Broken = len(broken)
+		for key := range broken {
+			if _, ok := firstSeen[key]; !ok {
+				firstSeen[key] = started
+				point.NewBroken++
+			}
+		}
+		for key, since := range firstSeen {
+			if !broken[key] {
+				point.Fixed++
+				fixed++
+				fixTime += started.Sub(since)
+				delete(firstSeen, key)
+			}
+		}
+		if point.Links > 0 {
+			point.NewBreakageRate = float64(point.NewBroken) / float64(point.Links)
+		}
+		if fixed > 0 {
+			point.MeanTimeToFix = fixTime.Hours() / float64(fixed)
+		}
+		rates += point.NewBreakageRate
+		trend.Runs = append(trend.Runs, point)
+	}
+	if len(trend.Runs) > 0 {
+		last := trend.Runs[len(trend.Runs)-1]
+		trend.OpenBroken = last.Broken
+		trend.MeanTimeToFix = last.MeanTimeToFix
+		trend.NewBreakageRate = rates / float64(len(trend.Runs))
+	}
+	return trend
+}
+
+// Writes trends as a JSON document
+func writeTrendsJson(trends []RepoTrend, out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(trends)
+}
+
+// Writes trends as CSV, one row per repository's run
+func writeTrendsCsv(trends []RepoTrend, out io.Writer) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"owner", "repository", "run", "links", "broken", "new_broken", "fixed", "new_breakage_rate", "mean_time_to_fix_hours"})
+	for _, t := range trends {
+		for _, p := range t.Runs {
+			w.Write([]string{
+				t.Owner, t.Repository, p.Run.Format(time.RFC3339),
+				strconv.Itoa(p.Links), strconv.Itoa(p.Broken), strconv.Itoa(p.NewBroken), strconv.Itoa(p.Fixed),
+				strconv.FormatFloat(p.NewBreakageRate, 'f', 4, 64), strconv.FormatFloat(p.MeanTimeToFix, 'f', 2, 64),
+			})
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// Writes trends in specified format (json or csv)
+func writeTrends(trends []RepoTrend, format string, out io.Writer) error {
+	switch format {
+	case "json":
+		return writeTrendsJson(trends, out)
+	case "csv":
+		return writeTrendsCsv(trends, out)
+	}
+	return errors.New("unknown trends format: " + format)
+}
+
+// GET /trends[?format=json|csv] returns trends of all stored repositories
+func (a *ArtifactStore) handleTrends(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		http.Error(w, "unknown format", http.StatusBadRequest)
+		return
+	}
+	a.mu.Lock()
+	trends, err := a.trends(requestKey(r))
+	a.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	writeTrends(trends, format, w)
+}
+
+// Returns "trends" command, which computes trends from stored run history
+func trendsCommand() *cli.Command {
+	var store ArtifactStore
+	var format, filename string
+	return &cli.Command{
+		Name:  "trends",
+		Usage: "Report broken links trends (per run counts, mean time-to-fix, new-breakage rate) from stored runs",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "reports-dir",
+				Value:       ".reports",
+				Usage:       "Directory with stored runs (see 'serve --reports-dir')",
+				EnvVars:     []string{"GMUV_REPORTS_DIR"},
+				Destination: &store.Dir,
+			},
+			&cli.StringFlag{
+				Name:    "storage",
+				Usage:   "Shared storage with stored runs (see 'serve --storage')",
+				EnvVars: []string{"GMUV_STORAGE"},
+			},
+			&cli.StringFlag{
+				Name:        "format",
+				Value:       "json",
+				Usage:       "Output format: json or csv",
+				Destination: &format,
+			},
+			&cli.StringFlag{
+				Name:        "out",
+				Usage:       "Output filename (default: console)",
+				Destination: &filename,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			var err error
+			if store.Backend, err = openStateStorage(c.String("storage"), store.Dir, "reports/"); err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+			trends, err := store.trends(nil)
+			if err != nil {
+				return cli.Exit("[ERR] Couldn't read stored runs: "+err.Error(), 1)
+			}
+			out := os.Stdout
+			if filename != "" {
+				if out, err = os.Create(filename); err != nil {
+					return err
+				}
+				defer out.Close()
+			}
+			return writeTrends(trends, format, out)
+		},
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version directory of a versioned docs tree: v1, 2.0, v3.1.x
+var versionDir = regexp.MustCompile(`^v?\d+(\.\d+)*(\.x)?$`)
+
+// Returns version directories (sorted from the oldest) by their parent directory. Parents with
+// a single version directory aren't versioned
+func findVersionedDocs(tree map[string]bool) map[string][]string {
+	versions := map[string][]string{}
+	for p, isDir := range tree {
+		if isDir && versionDir.MatchString(path.Base(p)) {
+			versions[path.Dir(p)] = append(versions[path.Dir(p)], path.Base(p))
+		}
+	}
+	for parent, dirs := range versions {
+		if len(dirs) < 2 {
+			delete(versions, parent)
+			continue
+		}
+		sort.Slice(dirs, func(i, j int) bool { return compareVersions(dirs[i], dirs[j]) < 0 })
+	}
+	return versions
+}
+
+// Compares version directory names numerically (v2 < v10)
+func compareVersions(a, b string) int {
+	parts := func(v string) []string {
+		return strings.Split(strings.TrimSuffix(strings.TrimPrefix(v, "v"), ".x"), ".")
+	}
+	pa, pb := parts(a), parts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			nb, _ = strconv.Atoi(pb[i])
+		}
+		if na != nb {
+			return na - nb
+		}
+	}
+	return len(pa) - len(pb)
+}
+
+// Splits repository path ("/docs/v1/guide.md") to versioned docs parent, version and path inside the version
+func splitVersionPath(p string, versions map[string][]string) (parent, version, rest string, ok bool) {
+	for dir := path.Dir(p); dir != "/" && dir != "."; dir = path.Dir(dir) {
+		if _, versioned := versions[path.Dir(dir)]; versioned && versionDir.MatchString(path.Base(dir)) {
+			return path.Dir(dir), path.Base(dir), strings.TrimPrefix(p, dir+"/"), true
+		}
+	}
+	return "", "", "", false
+}
+
+// Adds warnings about relative links of versioned docs, which cross into another version, and
+// about documents linked in older versions, which are missing in the latest version
+func (md *MdReport) checkVersionedDocs() {
+	versions := findVersionedDocs(md.Tree)
+	if len(versions) == 0 || md.MdFileList == nil {
+		return
+	}
+	missing := map[string]ReportWarning{} // first older version's link to a document missing in the latest version
+	for _, file := range *md.MdFileList {
+		fpath := "/" + *file.Path
+		parent, version, _, ok := splitVersionPath(fpath, versions)
+		if !ok {
+			continue
+		}
+		for _, link := range *file.LinkList {
+			if category, _ := classifyLink(md, *link.Link); category != linkInternal && category != linkAsset {
+				continue
+			}
+			target := normalizeSlashes(strings.TrimSpace(linkTarget(*link.Link)))
+			if strings.Contains(target, "://") {
+				continue
+			}
+			targetPath := resolveRepoPath(target, path.Dir(fpath)+"/", *file.Path)
+			targetParent, targetVersion, rest, ok := splitVersionPath(targetPath, versions)
+			switch {
+			case !ok || targetParent != parent:
+			case targetVersion != version:
+				md.addWarning(ReportWarning{Code: codeCrossVersion, Path: *file.Path, Line: *link.Line, Message: fmt.Sprintf("%s:%d links to %s of another docs version (%s instead of %s)", *file.Path, *link.Line, strings.TrimPrefix(targetPath, "/"), targetVersion, version)})
+			default:
+				dirs := versions[parent]
+				latest := path.Join(parent, dirs[len(dirs)-1], rest)
+				if _, exists := md.Tree[latest]; !exists && version != dirs[len(dirs)-1] && missing[latest].Path == "" {
+					missing[latest] = ReportWarning{Code: codeMissingInLatest, Path: *file.Path, Line: *link.Line}
+				}
+			}
+		}
+	}
+	documents := make([]string, 0, len(missing))
+	for latest := range missing {
+		documents = append(documents, latest)
+	}
+	sort.Strings(documents)
+	for _, latest := range documents {
+		w := missing[latest]
+		w.Message = fmt.Sprintf("%s is missing in the latest docs version, but %s:%d links to it", strings.TrimPrefix(latest, "/"), w.Path, w.Line)
+		md.addWarning(w)
+	}
+}